@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -10,7 +11,13 @@ import (
 	"time"
 
 	"galgame-gui/internal/api"
+	"galgame-gui/internal/backup"
 	"galgame-gui/internal/database"
+	"galgame-gui/internal/imagecache"
+	"galgame-gui/internal/links"
+	"galgame-gui/internal/models"
+	"galgame-gui/internal/outbox"
+	"galgame-gui/internal/sanitize"
 	ggsync "galgame-gui/internal/sync"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -28,6 +35,15 @@ type GameView struct {
 	Brand       string `json:"Brand"`
 	ReleaseDate string `json:"ReleaseDate"`
 	CoverURL    string `json:"CoverURL"`
+
+	ReleasePrecision string `json:"ReleasePrecision"`
+
+	OverriddenFields []string `json:"OverriddenFields"`
+	IsLocal          bool     `json:"IsLocal"`
+
+	CoverBlurhash      string `json:"CoverBlurhash"`
+	CoverDominantColor string `json:"CoverDominantColor"`
+	CoverAccentColor   string `json:"CoverAccentColor"`
 }
 
 type GameDetailsView struct {
@@ -42,20 +58,69 @@ type GameDetailsView struct {
 	Tags         string  `json:"tags"`
 	UpdatedAt    string  `json:"updated_at"`
 	DownloadLink *string `json:"download_link,omitempty"`
+
+	ReleasePrecision string `json:"release_precision"`
+
+	OverriddenFields []string `json:"overridden_fields,omitempty"`
+	IsLocal          bool     `json:"is_local"`
+
+	CoverBlurhash      string `json:"cover_blurhash,omitempty"`
+	CoverDominantColor string `json:"cover_dominant_color,omitempty"`
+	CoverAccentColor   string `json:"cover_accent_color,omitempty"`
+
+	DownloadLinks []links.DownloadLink `json:"download_links,omitempty"`
+	LinkChecks    []models.LinkCheck   `json:"link_checks,omitempty"`
+
+	UserStatus string `json:"user_status,omitempty"`
+	UserRating int    `json:"user_rating,omitempty"`
+	UserNotes  string `json:"user_notes,omitempty"`
+}
+
+type LocalGameInput struct {
+	TitleJP      string `json:"title_jp"`
+	TitleCN      string `json:"title_cn"`
+	Brand        string `json:"brand"`
+	ReleaseDate  string `json:"release_date"`
+	Synopsis     string `json:"synopsis"`
+	CoverURL     string `json:"cover_url"`
+	PreviewURLs  string `json:"preview_urls"`
+	Tags         string `json:"tags"`
+	DownloadLink string `json:"download_link"`
 }
 
 type App struct {
 	ctx        context.Context
 	db         *database.Service
 	apiClient  *api.Client
-	isSyncing  bool
-	syncMutex  sync.Mutex
-	isReady    bool
-	readyMutex sync.Mutex
+	backups    *backup.Manager
+	images     *imagecache.Cache
+	prefetcher *imagecache.Prefetcher
+
+	linkChecker     *links.Checker
+	linkReports     *outbox.Sender
+	isCheckingLinks bool
+	linkCheckCancel context.CancelFunc
+	linkCheckDone   chan struct{}
+	linkCheckMutex  sync.Mutex
+
+	syncScheduler  *ggsync.Scheduler
+	isSyncing      bool
+	lastSyncReport *ggsync.Report
+	syncMutex      sync.Mutex
+	isReady        bool
+	readyMutex     sync.Mutex
 }
 
 func NewApp() *App {
-	return &App{}
+	app := &App{}
+
+	images, err := imagecache.New("image-cache", imagecache.DefaultQuota)
+	if err != nil {
+		log.Printf("初始化图片缓存失败, 将直接加载远程图片: %v", err)
+	} else {
+		app.images = images
+	}
+	return app
 }
 
 func stringFromPtr(s *string) string {
@@ -65,6 +130,14 @@ func stringFromPtr(s *string) string {
 	return *s
 }
 
+func ptrFromString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (a *App) OnStartup(ctx context.Context) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -86,10 +159,37 @@ func (a *App) OnStartup(ctx context.Context) {
 		log.Fatalf("应用初始化：无法初始化本地数据库: %v", err)
 	}
 
+	a.backups = backup.NewManager(a.db, backup.DefaultInterval, backup.DefaultKeep)
+	a.backups.Start()
+
+	if a.images != nil {
+		a.images.SetOnStore(a.saveCoverMeta)
+		a.loadImageHostRules()
+		a.prefetcher = imagecache.NewPrefetcher(a.images, imagecache.DefaultPrefetchConcurrency, func(status imagecache.PrefetchStatus) {
+			runtime.EventsEmit(a.ctx, "prefetch-progress", status)
+		})
+		go a.backfillCoverMeta()
+	}
+
 	a.apiClient = api.NewClient(dataServiceURL, publicKey, privateKey)
+	a.linkChecker = links.NewChecker(links.DefaultCheckConcurrency, links.DefaultHostInterval)
+	a.linkReports = outbox.NewSender(a.db, a.apiClient, outbox.DefaultInterval)
+	a.linkReports.Start()
 
+	if n, err := ggsync.RecoverInterrupted(a.db); err != nil {
+		log.Printf("%v", err)
+	} else if n > 0 {
+		log.Printf("数据同步：%d 次上次未完成的同步已标记为失败", n)
+	}
 	go a.runSync()
 
+	interval, err := ggsync.LoadInterval(a.db)
+	if err != nil {
+		log.Printf("读取同步间隔失败, 使用默认值: %v", err)
+	}
+	a.syncScheduler = ggsync.NewScheduler(a.db, interval, a.syncOnce)
+	a.syncScheduler.Start()
+
 	a.readyMutex.Lock()
 	a.isReady = true
 	a.readyMutex.Unlock()
@@ -98,12 +198,25 @@ func (a *App) OnStartup(ctx context.Context) {
 }
 
 func (a *App) OnShutdown(ctx context.Context) {
+	if a.prefetcher != nil {
+		a.prefetcher.Cancel()
+	}
+	if a.syncScheduler != nil {
+		a.syncScheduler.Stop()
+	}
+	a.stopLinkCheck()
+	if a.linkReports != nil {
+		a.linkReports.Stop()
+	}
+	if a.backups != nil {
+		a.backups.Stop()
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
 }
 
-func (a *App) GetGames(keyword string, limit int, offset int) ([]GameView, error) {
+func buildSearchClause(keyword string) (string, []interface{}) {
 	keyword = strings.TrimSpace(keyword)
 	keywords := strings.Fields(keyword)
 	var args []interface{}
@@ -117,7 +230,12 @@ func (a *App) GetGames(keyword string, limit int, offset int) ([]GameView, error
 		}
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
-	query := fmt.Sprintf(`SELECT id, title_jp, title_cn, brand, release_date, cover_url FROM games %s ORDER BY release_date DESC LIMIT ? OFFSET ?`, whereClause)
+	return whereClause, args
+}
+
+func (a *App) GetGames(keyword string, limit int, offset int) ([]GameView, error) {
+	whereClause, args := buildSearchClause(keyword)
+	query := fmt.Sprintf(`SELECT id, title_jp, title_cn, brand, release_date, release_precision, cover_url, overridden_fields, is_local, cover_blurhash, cover_dominant_color, cover_accent_color FROM games_merged %s ORDER BY release_date DESC LIMIT ? OFFSET ?`, whereClause)
 	args = append(args, limit, offset)
 	rows, err := a.db.Query(query, args...)
 	if err != nil {
@@ -132,60 +250,198 @@ func (a *App) GetGames(keyword string, limit int, offset int) ([]GameView, error
 	var games []GameView
 	for rows.Next() {
 		var id int64
-		var titleJP, titleCN, brand, releaseDate, coverURL sql.NullString
-		if err := rows.Scan(&id, &titleJP, &titleCN, &brand, &releaseDate, &coverURL); err != nil {
+		var isLocal bool
+		var releaseDate sql.NullTime
+		var releasePrecision string
+		var titleJP, titleCN, brand, coverURL, overriddenFields sql.NullString
+		var blurhash, dominantColor, accentColor sql.NullString
+		if err := rows.Scan(&id, &titleJP, &titleCN, &brand, &releaseDate, &releasePrecision, &coverURL, &overriddenFields, &isLocal,
+			&blurhash, &dominantColor, &accentColor); err != nil {
 			log.Printf("扫描游戏列表行失败: %v", err)
 			continue
 		}
 
-		formattedReleaseDate := ""
-		if releaseDate.Valid && releaseDate.String != "" {
-			t, err := time.Parse(time.RFC3339, releaseDate.String)
-			if err == nil {
-				formattedReleaseDate = t.Format("2006-01-02")
-			} else {
-				log.Printf("仍然无法解析 release_date '%s': %v", releaseDate.String, err)
-			}
-		}
-
 		games = append(games, GameView{
 			ID:          id,
 			TitleJP:     titleJP.String,
 			TitleCN:     titleCN.String,
 			Brand:       brand.String,
-			ReleaseDate: formattedReleaseDate,
-			CoverURL:    coverURL.String,
+			ReleaseDate: models.ReleaseDateFromSortKey(releaseDate.Time, releasePrecision).String(),
+			CoverURL:    a.thumbnailURL(coverURL.String),
+
+			ReleasePrecision: releasePrecision,
+
+			OverriddenFields: database.SplitOverriddenFields(overriddenFields.String),
+			IsLocal:          isLocal,
+
+			CoverBlurhash:      blurhash.String,
+			CoverDominantColor: dominantColor.String,
+			CoverAccentColor:   accentColor.String,
 		})
 	}
 	return games, nil
 }
 
+// gameView 把完整的游戏数据转换为列表视图, 与 GetGames 的输出一致。
+func (a *App) gameView(game models.Galgame) GameView {
+	return GameView{
+		ID:          game.ID,
+		TitleJP:     game.TitleJP,
+		TitleCN:     stringFromPtr(game.TitleCN),
+		Brand:       stringFromPtr(game.Brand),
+		ReleaseDate: game.ReleaseDate.String(),
+		CoverURL:    a.thumbnailURL(stringFromPtr(game.CoverURL)),
+
+		ReleasePrecision: game.ReleaseDate.Precision,
+
+		OverriddenFields: game.OverriddenFields,
+		IsLocal:          game.IsLocal,
+
+		CoverBlurhash:      game.CoverBlurhash,
+		CoverDominantColor: game.CoverDominantColor,
+		CoverAccentColor:   game.CoverAccentColor,
+	}
+}
+
 func (a *App) GetGameDetails(id int64) (GameDetailsView, error) {
 	game, err := a.db.GetGameByID(id)
 	if err != nil {
 		return GameDetailsView{}, err
 	}
+	userGame, err := a.db.GetUserGame(id)
+	if err != nil {
+		return GameDetailsView{}, err
+	}
+	linkChecks, err := a.db.GetLinkChecks(id)
+	if err != nil {
+		return GameDetailsView{}, err
+	}
 	gameView := GameDetailsView{
 		ID:           game.ID,
 		TitleJP:      game.TitleJP,
 		TitleCN:      stringFromPtr(game.TitleCN),
 		Brand:        stringFromPtr(game.Brand),
-		ReleaseDate:  game.ReleaseDate.Format("2006-01-02"),
+		ReleaseDate:  game.ReleaseDate.String(),
 		Synopsis:     stringFromPtr(game.Synopsis),
-		CoverURL:     stringFromPtr(game.CoverURL),
-		PreviewURLs:  game.PreviewURLs,
+		CoverURL:     a.imageURL(stringFromPtr(game.CoverURL)),
+		PreviewURLs:  a.imageURLList(game.PreviewURLs),
 		Tags:         stringFromPtr(game.Tags),
 		UpdatedAt:    game.UpdatedAt.Format(time.RFC3339),
 		DownloadLink: game.DownloadLink,
+
+		ReleasePrecision: game.ReleaseDate.Precision,
+
+		OverriddenFields: game.OverriddenFields,
+		IsLocal:          game.IsLocal,
+
+		CoverBlurhash:      game.CoverBlurhash,
+		CoverDominantColor: game.CoverDominantColor,
+		CoverAccentColor:   game.CoverAccentColor,
+
+		DownloadLinks: links.ParseDownloadField(stringFromPtr(game.DownloadLink)),
+		LinkChecks:    linkChecks,
+
+		UserStatus: userGame.Status,
+		UserRating: userGame.Rating,
+		UserNotes:  userGame.Notes,
 	}
 	return gameView, nil
 }
 
+func (a *App) SetUserGame(id int64, status string, rating int, notes string) error {
+	if _, err := a.db.GetGameByID(id); err != nil {
+		return err
+	}
+	err := a.db.SetUserGame(models.UserGame{GameID: id, Status: status, Rating: rating, Notes: notes})
+	if err != nil {
+		log.Printf("保存游戏ID %d 的用户数据失败: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (a *App) SetGameOverride(id int64, field string, value string) error {
+	value, err := sanitize.Field(field, value)
+	if err != nil {
+		return fmt.Errorf("字段 '%s' 的值无效: %w", field, err)
+	}
+	if err := a.db.SetOverride(id, field, value); err != nil {
+		log.Printf("设置游戏ID %d 的字段覆盖 '%s' 失败: %v", id, field, err)
+		return err
+	}
+	return nil
+}
+
+func (a *App) ClearGameOverride(id int64, field string) error {
+	if err := a.db.ClearOverride(id, field); err != nil {
+		log.Printf("清除游戏ID %d 的字段覆盖 '%s' 失败: %v", id, field, err)
+		return err
+	}
+	return nil
+}
+
+func (input LocalGameInput) toModel() (models.Galgame, error) {
+	game := models.Galgame{
+		TitleJP:      strings.TrimSpace(input.TitleJP),
+		TitleCN:      ptrFromString(input.TitleCN),
+		Brand:        ptrFromString(input.Brand),
+		Synopsis:     ptrFromString(input.Synopsis),
+		CoverURL:     ptrFromString(input.CoverURL),
+		PreviewURLs:  ptrFromString(input.PreviewURLs),
+		Tags:         ptrFromString(input.Tags),
+		DownloadLink: ptrFromString(input.DownloadLink),
+	}
+	releaseDate, err := models.ParseReleaseDate(input.ReleaseDate)
+	if err != nil {
+		return models.Galgame{}, fmt.Errorf("无法解析发售日期 '%s', 请使用 YYYY-MM-DD、YYYY-MM、YYYY、2026年春 或 未定 等格式", input.ReleaseDate)
+	}
+	game.ReleaseDate = releaseDate
+	return sanitize.Game(game)
+}
+
+func (a *App) AddLocalGame(input LocalGameInput) (int64, error) {
+	game, err := input.toModel()
+	if err != nil {
+		return 0, err
+	}
+	id, err := a.db.AddLocalGame(game)
+	if err != nil {
+		log.Printf("添加本地游戏失败: %v", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (a *App) EditLocalGame(id int64, input LocalGameInput) error {
+	game, err := input.toModel()
+	if err != nil {
+		return err
+	}
+	if err := a.db.EditLocalGame(id, game); err != nil {
+		log.Printf("编辑本地游戏ID %d 失败: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (a *App) DeleteLocalGame(id int64) error {
+	if err := a.db.DeleteLocalGame(id); err != nil {
+		log.Printf("删除本地游戏ID %d 失败: %v", id, err)
+		return err
+	}
+	return nil
+}
+
 func (a *App) runSync() {
+	_, _ = a.syncOnce()
+}
+
+// syncOnce 执行一次同步, 已有同步 (或撤销、恢复备份) 在进行时直接返回 false。
+func (a *App) syncOnce() (bool, error) {
 	a.syncMutex.Lock()
 	if a.isSyncing {
 		a.syncMutex.Unlock()
-		return
+		return false, nil
 	}
 	a.isSyncing = true
 	a.syncMutex.Unlock()
@@ -194,13 +450,32 @@ func (a *App) runSync() {
 		a.isSyncing = false
 		a.syncMutex.Unlock()
 	}()
+	runtime.EventsEmit(a.ctx, "sync-started")
 	log.Println("数据同步：后台数据同步开始...")
-	err := ggsync.Run(a.db, a.apiClient)
+	report, err := ggsync.Run(a.db, a.apiClient)
+	if errors.Is(err, ggsync.ErrLocked) {
+		log.Println("数据同步：另一个进程正在同步, 本次跳过。")
+		runtime.EventsEmit(a.ctx, "sync-skipped", err.Error())
+		return false, nil
+	}
 	if err != nil {
 		log.Printf("数据同步：同步失败: %v", err)
 	} else {
-		log.Println("数据同步：后台同步成功。")
+		log.Printf("数据同步：后台同步成功。更新 %d 条, 删除 %d 条, 拒绝 %d 条。", report.Upserted, report.Deleted, len(report.RowErrors))
 	}
+
+	a.syncMutex.Lock()
+	a.lastSyncReport = &report
+	a.syncMutex.Unlock()
+	runtime.EventsEmit(a.ctx, "sync-report", report)
+	return true, err
+}
+
+// GetLastSyncReport 返回本次运行中最近一次同步的结果, 尚未同步过时返回 nil。
+func (a *App) GetLastSyncReport() *ggsync.Report {
+	a.syncMutex.Lock()
+	defer a.syncMutex.Unlock()
+	return a.lastSyncReport
 }
 
 func (a *App) TriggerSync() {
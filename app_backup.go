@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"galgame-gui/internal/backup"
+	"galgame-gui/internal/database"
+	ggsync "galgame-gui/internal/sync"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func (a *App) ListBackups() ([]backup.Info, error) {
+	return a.backups.List()
+}
+
+func (a *App) CreateBackup() (backup.Info, error) {
+	info, err := a.backups.Snapshot(database.BackupKindManual)
+	if err != nil {
+		log.Printf("手动备份失败: %v", err)
+		return backup.Info{}, err
+	}
+	return info, nil
+}
+
+// RestoreBackup 在恢复期间占用同步标志和同步锁, 避免本进程或其他进程的同步在数据被替换时写入数据库。
+func (a *App) RestoreBackup(name string) error {
+	a.syncMutex.Lock()
+	if a.isSyncing {
+		a.syncMutex.Unlock()
+		return fmt.Errorf("正在同步数据, 请在同步完成后再恢复备份")
+	}
+	a.isSyncing = true
+	a.syncMutex.Unlock()
+	defer func() {
+		a.syncMutex.Lock()
+		a.isSyncing = false
+		a.syncMutex.Unlock()
+	}()
+
+	// 同步锁保证其他进程 (例如 --multi-instance 打开的另一个窗口) 不会在替换数据库时同步
+	err := ggsync.WithLease(a.db, func(ctx context.Context) error {
+		return a.backups.Restore(name)
+	})
+	if err != nil {
+		log.Printf("恢复备份 %s 失败: %v", name, err)
+		return err
+	}
+	runtime.EventsEmit(a.ctx, "backup-restored", name)
+	return nil
+}
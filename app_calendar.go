@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"galgame-gui/internal/export"
+	"galgame-gui/internal/models"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const defaultUpcomingRange = 365 * 24 * time.Hour
+
+// ReleaseGroup 是发售日历中的一组游戏, 同一天、同一月、同一季度或同一年 (按精度) 的游戏归为一组。
+type ReleaseGroup struct {
+	Label     string     `json:"label"`
+	Date      string     `json:"date"`
+	Precision string     `json:"precision"`
+	Games     []GameView `json:"games"`
+}
+
+var precisionRank = map[string]int{
+	models.PrecisionDay:    0,
+	models.PrecisionMonth:  1,
+	models.PrecisionSeason: 2,
+	models.PrecisionYear:   3,
+}
+
+func parseCalendarDate(s string, fallback time.Time) (time.Time, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法解析日期 '%s', 请使用 YYYY-MM-DD 格式", s)
+	}
+	return t, nil
+}
+
+// GetUpcomingReleases 按发售日期分组返回 [from, to] 之间发售的游戏 (YYYY-MM-DD, from 默认为今天,
+// to 默认为一年后)。只知道月份、季节或年份的游戏只要时间段与范围有交集就会列出,
+// 各组按时间段结束的先后排列, 日期未定的游戏放在最后一组。
+func (a *App) GetUpcomingReleases(from string, to string) ([]ReleaseGroup, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	fromDate, err := parseCalendarDate(from, today)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := parseCalendarDate(to, fromDate.Add(defaultUpcomingRange))
+	if err != nil {
+		return nil, err
+	}
+	if toDate.Before(fromDate) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+
+	games, err := a.db.ListReleasesBetween(fromDate, toDate, true)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		ri, rj := games[i].ReleaseDate, games[j].ReleaseDate
+		unknownI, unknownJ := ri.Precision == models.PrecisionUnknown, rj.Precision == models.PrecisionUnknown
+		if unknownI || unknownJ {
+			return !unknownI && unknownJ
+		}
+		if !ri.End().Equal(rj.End()) {
+			return ri.End().Before(rj.End())
+		}
+		return precisionRank[ri.Precision] < precisionRank[rj.Precision]
+	})
+
+	groups := []ReleaseGroup{}
+	for _, game := range games {
+		label := game.ReleaseDate.String()
+		if n := len(groups); n > 0 && groups[n-1].Label == label {
+			groups[n-1].Games = append(groups[n-1].Games, a.gameView(game))
+			continue
+		}
+		groups = append(groups, ReleaseGroup{
+			Label:     label,
+			Date:      game.ReleaseDate.ISO(),
+			Precision: game.ReleaseDate.Precision,
+			Games:     []GameView{a.gameView(game)},
+		})
+	}
+	return groups, nil
+}
+
+// ExportCalendar 把愿望单中的游戏和今天起一年内发售的游戏导出为 .ics 日历文件, 用户取消时返回空路径。
+func (a *App) ExportCalendar() (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出发售日历",
+		DefaultFilename: fmt.Sprintf("ShiroGal-发售日历-%s%s", time.Now().Format("20060102"), export.CalendarExtension),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "iCalendar 日历 (*.ics)", Pattern: "*" + export.CalendarExtension},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("打开保存对话框失败: %w", err)
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	userGames, err := a.db.ListUserGames()
+	if err != nil {
+		return "", err
+	}
+	wishIDs, err := a.db.ListUserGameIDs(models.UserStatusWish)
+	if err != nil {
+		return "", err
+	}
+	wishlist, err := a.db.GetGamesByIDs(wishIDs)
+	if err != nil {
+		return "", err
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	upcoming, err := a.db.ListReleasesBetween(today, today.Add(defaultUpcomingRange), false)
+	if err != nil {
+		return "", err
+	}
+
+	seen := make(map[int64]bool)
+	var events []export.CalendarEvent
+	for _, game := range append(wishlist, upcoming...) {
+		if seen[game.ID] {
+			continue
+		}
+		seen[game.ID] = true
+		if event, ok := export.CalendarEventFromGame(game, userGames[game.ID]); ok {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	if err := export.WriteCalendarFile(path, events); err != nil {
+		log.Printf("导出日历失败: %v", err)
+		return "", err
+	}
+	log.Printf("已导出 %d 个发售事件到 %s", len(events), path)
+	return path, nil
+}
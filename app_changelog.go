@@ -0,0 +1,46 @@
+package main
+
+import "galgame-gui/internal/models"
+
+// Changelog 汇总若干次同步的变更。FieldCounts 按字段统计被更新的游戏数,
+// 界面可以据此显示 "新增 12 个游戏, 3 个下载链接有更新"。
+type Changelog struct {
+	Runs        []models.SyncRun    `json:"runs"`
+	Added       int                 `json:"added"`
+	Updated     int                 `json:"updated"`
+	Removed     int                 `json:"removed"`
+	FieldCounts map[string]int      `json:"field_counts"`
+	Changes     []models.SyncChange `json:"changes"`
+}
+
+// GetChangelog 返回 sinceRun 之后所有同步的变更; sinceRun 为空时返回最近一次同步的变更。
+func (a *App) GetChangelog(sinceRun string) (Changelog, error) {
+	runs, err := a.db.ListSyncRunsAfter(sinceRun)
+	if err != nil {
+		return Changelog{}, err
+	}
+	runIDs := make([]string, len(runs))
+	for i, run := range runs {
+		runIDs[i] = run.ID
+	}
+	changes, err := a.db.ListSyncChanges(runIDs)
+	if err != nil {
+		return Changelog{}, err
+	}
+
+	changelog := Changelog{Runs: runs, FieldCounts: map[string]int{}, Changes: changes}
+	for _, change := range changes {
+		switch change.Type {
+		case models.ChangeAdded:
+			changelog.Added++
+		case models.ChangeUpdated:
+			changelog.Updated++
+			for _, field := range change.Fields {
+				changelog.FieldCounts[field.Field]++
+			}
+		case models.ChangeRemoved:
+			changelog.Removed++
+		}
+	}
+	return changelog, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"galgame-gui/internal/export"
+	"galgame-gui/internal/models"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func (a *App) ExportSearchResults(keyword string, format string) (string, error) {
+	whereClause, args := buildSearchClause(keyword)
+	games, err := a.db.ListGames(whereClause, args...)
+	if err != nil {
+		return "", err
+	}
+	return a.exportGames("ShiroGal-搜索结果", format, games)
+}
+
+func (a *App) ExportGames(ids []int64, format string) (string, error) {
+	games, err := a.db.GetGamesByIDs(ids)
+	if err != nil {
+		return "", err
+	}
+	return a.exportGames("ShiroGal-选中游戏", format, games)
+}
+
+func (a *App) ExportLibrary(format string) (string, error) {
+	games, err := a.db.ListGames("")
+	if err != nil {
+		return "", err
+	}
+	return a.exportGames("ShiroGal-全部游戏", format, games)
+}
+
+// exportGames 弹出保存对话框并写入文件, 用户取消时返回空路径。
+func (a *App) exportGames(baseName string, formatName string, games []models.Galgame) (string, error) {
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出游戏数据",
+		DefaultFilename: fmt.Sprintf("%s-%s%s", baseName, time.Now().Format("20060102"), format.Extension()),
+		Filters: []runtime.FileFilter{
+			{DisplayName: format.DisplayName(), Pattern: "*" + format.Extension()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("打开保存对话框失败: %w", err)
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	userGames, err := a.db.ListUserGames()
+	if err != nil {
+		return "", err
+	}
+
+	if err := export.WriteFile(path, format, export.FromGames(games, userGames)); err != nil {
+		log.Printf("导出失败: %v", err)
+		return "", err
+	}
+	log.Printf("已导出 %d 个游戏到 %s", len(games), path)
+	return path, nil
+}
@@ -0,0 +1,11 @@
+package main
+
+import "galgame-gui/internal/models"
+
+// GetGameHistory 返回游戏同步数据的变更时间线, 最新的在前。字段覆盖和本地游戏的编辑不在其中。
+func (a *App) GetGameHistory(id int64) ([]models.GameHistoryEntry, error) {
+	if _, err := a.db.GetGameByID(id); err != nil {
+		return nil, err
+	}
+	return a.db.GetGameHistory(id)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"galgame-gui/internal/imagecache"
+	"galgame-gui/internal/imagemeta"
+)
+
+// newAssetHandler 为 AssetServer 提供 /img/ 下的本地图片缓存, 其余请求交给 fallback。
+func (a *App) newAssetHandler(fallback http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(imagecache.RoutePrefix, func(w http.ResponseWriter, r *http.Request) {
+		if a.images == nil {
+			http.NotFound(w, r)
+			return
+		}
+		a.images.ServeHTTP(w, r)
+	})
+	mux.Handle("/", fallback)
+	return mux
+}
+
+func (a *App) imageURL(rawURL string) string {
+	if a.images == nil {
+		return rawURL
+	}
+	return a.images.URLFor(rawURL)
+}
+
+func (a *App) thumbnailURL(rawURL string) string {
+	if a.images == nil {
+		return rawURL
+	}
+	return a.images.ThumbnailURLFor(rawURL, imagecache.GridThumbnailWidth)
+}
+
+func (a *App) imageURLList(rawURLs *string) *string {
+	if a.images == nil || rawURLs == nil {
+		return rawURLs
+	}
+	rewritten := a.images.RewriteList(*rawURLs, ",")
+	return &rewritten
+}
+
+// saveCoverMeta 在封面进入缓存后计算 blurhash 和主色, 预览图等非封面图片会被忽略。
+func (a *App) saveCoverMeta(rawURL string, data []byte) {
+	isCover, err := a.db.IsCoverURL(rawURL)
+	if err != nil || !isCover {
+		return
+	}
+	meta, err := imagemeta.Analyze(data)
+	if err != nil {
+		log.Printf("封面占位信息：分析 %s 失败: %v", rawURL, err)
+		return
+	}
+	if err := a.db.SaveImageMeta(rawURL, meta.Blurhash, meta.DominantColor, meta.AccentColor); err != nil {
+		log.Printf("封面占位信息：%v", err)
+	}
+}
+
+// backfillCoverMeta 为在此功能之前就已缓存的封面补算占位信息。
+func (a *App) backfillCoverMeta() {
+	urls, err := a.db.ListCoverURLsWithoutMeta()
+	if err != nil {
+		log.Printf("封面占位信息：%v", err)
+		return
+	}
+	count := 0
+	for _, rawURL := range urls {
+		data, ok := a.images.Read(rawURL)
+		if !ok {
+			continue
+		}
+		a.saveCoverMeta(rawURL, data)
+		count++
+	}
+	if count > 0 {
+		log.Printf("封面占位信息：已为 %d 个已缓存的封面补算占位信息", count)
+	}
+}
+
+const imageHostRulesSettingKey = "image_host_rules"
+
+// GetImageHostRules 返回用户保存的图片站点规则, 不包含内置的默认规则。
+func (a *App) GetImageHostRules() (map[string]imagecache.HostRule, error) {
+	raw, ok, err := a.db.GetSetting(imageHostRulesSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	rules := map[string]imagecache.HostRule{}
+	if !ok || raw == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("解析图片站点规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// SetImageHostRules 保存图片站点规则并立即生效, 同名站点覆盖内置的默认规则。
+func (a *App) SetImageHostRules(rules map[string]imagecache.HostRule) error {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	if err := a.db.SetSetting(imageHostRulesSettingKey, string(data)); err != nil {
+		return err
+	}
+	if a.images != nil {
+		a.images.Fetcher().SetHostRules(rules)
+	}
+	return nil
+}
+
+// loadImageHostRules 在启动时把设置中的图片站点规则登记到下载器。
+func (a *App) loadImageHostRules() {
+	rules, err := a.GetImageHostRules()
+	if err != nil {
+		log.Printf("图片缓存：读取图片站点规则失败, 只使用默认规则: %v", err)
+		return
+	}
+	a.images.Fetcher().SetHostRules(rules)
+}
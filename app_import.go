@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"galgame-gui/internal/importer"
+	"galgame-gui/internal/models"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type ImportPreview struct {
+	Source         string           `json:"source"`
+	FileName       string           `json:"file_name"`
+	Matches        []importer.Match `json:"matches"`
+	AutoCount      int              `json:"auto_count"`
+	ReviewCount    int              `json:"review_count"`
+	UnmatchedCount int              `json:"unmatched_count"`
+}
+
+type ImportItem struct {
+	GameID int64  `json:"game_id"`
+	Status string `json:"status"`
+	Rating int    `json:"rating"`
+	Notes  string `json:"notes"`
+}
+
+// PreviewImport 让用户选择 VNDB 或 Bangumi 的导出文件并返回匹配结果, 不写入任何数据;
+// 用户确认 (或修正) 匹配后再调用 ApplyImport。
+func (a *App) PreviewImport(source string) (ImportPreview, error) {
+	filters := []runtime.FileFilter{{DisplayName: "Bangumi 收藏导出 (*.json)", Pattern: "*.json"}}
+	if source == importer.SourceVNDB {
+		filters = []runtime.FileFilter{{DisplayName: "VNDB 列表导出 (*.xml;*.json)", Pattern: "*.xml;*.json"}}
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "选择要导入的文件",
+		Filters: filters,
+	})
+	if err != nil {
+		return ImportPreview{}, fmt.Errorf("打开文件对话框失败: %w", err)
+	}
+	if path == "" {
+		return ImportPreview{}, nil
+	}
+
+	entries, err := importer.ParseFile(source, path)
+	if err != nil {
+		log.Printf("解析导入文件 %s 失败: %v", path, err)
+		return ImportPreview{}, err
+	}
+
+	games, err := a.db.ListGames("")
+	if err != nil {
+		return ImportPreview{}, err
+	}
+	matcher := importer.NewMatcher(games)
+
+	preview := ImportPreview{
+		Source:   source,
+		FileName: filepath.Base(path),
+		Matches:  make([]importer.Match, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		match := matcher.Match(entry)
+		switch match.Decision {
+		case importer.DecisionAuto:
+			preview.AutoCount++
+		case importer.DecisionReview:
+			preview.ReviewCount++
+		default:
+			preview.UnmatchedCount++
+		}
+		preview.Matches = append(preview.Matches, match)
+	}
+	log.Printf("导入预览：%s 共 %d 条, 自动匹配 %d, 待确认 %d, 未匹配 %d",
+		preview.FileName, len(entries), preview.AutoCount, preview.ReviewCount, preview.UnmatchedCount)
+	return preview, nil
+}
+
+func (a *App) ApplyImport(items []ImportItem) (int, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.GameID)
+	}
+	games, err := a.db.GetGamesByIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[int64]struct{}, len(games))
+	for _, g := range games {
+		known[g.ID] = struct{}{}
+	}
+
+	userGames := make([]models.UserGame, 0, len(items))
+	for _, item := range items {
+		if _, ok := known[item.GameID]; !ok {
+			log.Printf("导入：跳过不存在的游戏ID %d", item.GameID)
+			continue
+		}
+		userGames = append(userGames, models.UserGame{
+			GameID: item.GameID,
+			Status: item.Status,
+			Rating: item.Rating,
+			Notes:  item.Notes,
+		})
+	}
+
+	count, err := a.db.MergeUserGames(userGames)
+	if err != nil {
+		log.Printf("导入用户数据失败: %v", err)
+		return 0, err
+	}
+	log.Printf("导入：已写入 %d 条用户数据", count)
+	return count, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	singleInstanceID = "shirogal-5e0f3c8a-single-instance"
+	// 带上这个参数启动时允许同时打开多个窗口, 它们共用同一个数据库, 由同步锁保证只有一个在同步
+	multiInstanceFlag = "--multi-instance"
+)
+
+// SecondInstanceArgs 是再次启动时转发给已运行窗口的参数。
+type SecondInstanceArgs struct {
+	Args             []string `json:"args"`
+	WorkingDirectory string   `json:"working_directory"`
+}
+
+func singleInstanceLock(app *App, args []string) *options.SingleInstanceLock {
+	for _, arg := range args {
+		if arg == multiInstanceFlag {
+			return nil
+		}
+	}
+	return &options.SingleInstanceLock{
+		UniqueId:               singleInstanceID,
+		OnSecondInstanceLaunch: app.onSecondInstanceLaunch,
+	}
+}
+
+// onSecondInstanceLaunch 在用户再次启动 ShiroGal 时把已运行的窗口带到前台, 并把启动参数转发给前端。
+func (a *App) onSecondInstanceLaunch(data options.SecondInstanceData) {
+	if a.ctx == nil {
+		return
+	}
+	log.Printf("检测到再次启动, 参数: %v", data.Args)
+	runtime.WindowUnminimise(a.ctx)
+	runtime.Show(a.ctx)
+	runtime.EventsEmit(a.ctx, "second-instance", SecondInstanceArgs{
+		Args:             data.Args,
+		WorkingDirectory: data.WorkingDirectory,
+	})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"galgame-gui/internal/database"
+	"galgame-gui/internal/links"
+	"galgame-gui/internal/models"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type LinkCheckProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	Dead  int `json:"dead"`
+}
+
+func linkTargets(games []models.Galgame) []links.Target {
+	var targets []links.Target
+	for _, game := range games {
+		for _, link := range links.ParseDownloadField(stringFromPtr(game.DownloadLink)) {
+			if link.Kind == links.KindMagnet {
+				continue
+			}
+			targets = append(targets, links.Target{GameID: game.ID, URL: link.URL, Code: link.Code})
+		}
+	}
+	return targets
+}
+
+// CheckGameLinks 立即探测单个游戏的全部下载链接并保存结果。
+func (a *App) CheckGameLinks(id int64) ([]models.LinkCheck, error) {
+	game, err := a.db.GetGameByID(id)
+	if err != nil {
+		return nil, err
+	}
+	results := a.linkChecker.Check(a.ctx, linkTargets([]models.Galgame{game}), nil)
+	if err := a.db.SaveLinkChecks(results); err != nil {
+		log.Printf("保存游戏ID %d 的链接检查结果失败: %v", id, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// CheckAllLinks 在后台探测所有游戏的下载链接, 进度通过 link-check-progress 事件推送,
+// 结束时发送 link-check-done。
+func (a *App) CheckAllLinks() error {
+	a.linkCheckMutex.Lock()
+	if a.isCheckingLinks {
+		a.linkCheckMutex.Unlock()
+		return fmt.Errorf("链接检查已在进行中")
+	}
+	a.isCheckingLinks = true
+	a.linkCheckMutex.Unlock()
+
+	games, err := a.db.ListGames("")
+	if err != nil {
+		a.linkCheckMutex.Lock()
+		a.isCheckingLinks = false
+		a.linkCheckMutex.Unlock()
+		return err
+	}
+	targets := linkTargets(games)
+
+	// 检查可能持续很久, 使用可取消的 context, 以便用户取消或程序退出时停止
+	ctx, cancel := context.WithCancel(a.ctx)
+	done := make(chan struct{})
+	a.linkCheckMutex.Lock()
+	a.linkCheckCancel, a.linkCheckDone = cancel, done
+	a.linkCheckMutex.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			a.linkCheckMutex.Lock()
+			a.isCheckingLinks = false
+			a.linkCheckCancel, a.linkCheckDone = nil, nil
+			a.linkCheckMutex.Unlock()
+			close(done)
+		}()
+		log.Printf("链接检查：开始检查 %d 条链接", len(targets))
+
+		var mu sync.Mutex
+		progress := LinkCheckProgress{Total: len(targets)}
+		results := a.linkChecker.Check(ctx, targets, func(result models.LinkCheck) {
+			mu.Lock()
+			progress.Done++
+			if result.Status == links.StatusDead {
+				progress.Dead++
+			}
+			snapshot := progress
+			mu.Unlock()
+			runtime.EventsEmit(a.ctx, "link-check-progress", snapshot)
+		})
+
+		if err := a.db.SaveLinkChecks(results); err != nil {
+			log.Printf("链接检查：保存结果失败: %v", err)
+		}
+		if ctx.Err() != nil {
+			log.Printf("链接检查：已取消, 已检查 %d/%d 条", len(results), progress.Total)
+		} else {
+			log.Printf("链接检查：完成, 共 %d 条, 失效 %d 条", progress.Total, progress.Dead)
+		}
+		runtime.EventsEmit(a.ctx, "link-check-done", progress)
+	}()
+	return nil
+}
+
+const maxReportReasonLength = 500
+
+// ReportLink 把失效链接反馈放入本地发件箱, 由后台发送到数据服务; 离线时会在恢复联网后补发。
+func (a *App) ReportLink(gameID int64, linkURL string, reason string) error {
+	if database.IsLocalGameID(gameID) {
+		return fmt.Errorf("本地游戏的链接无法反馈到数据服务")
+	}
+	if _, err := a.db.GetGameByID(gameID); err != nil {
+		return err
+	}
+	linkURL = strings.TrimSpace(linkURL)
+	if linkURL == "" {
+		return fmt.Errorf("链接不能为空")
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReportReasonLength {
+		return fmt.Errorf("反馈原因不能超过 %d 个字符", maxReportReasonLength)
+	}
+
+	if err := a.db.QueueLinkReport(gameID, linkURL, reason); err != nil {
+		return err
+	}
+	log.Printf("链接反馈：已记录游戏ID %d 的反馈", gameID)
+	a.linkReports.Wake()
+	return nil
+}
+
+// CancelLinkCheck 取消正在进行的全量链接检查, 已经得到的结果仍会保存。
+func (a *App) CancelLinkCheck() {
+	a.linkCheckMutex.Lock()
+	cancel := a.linkCheckCancel
+	a.linkCheckMutex.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// stopLinkCheck 取消正在进行的链接检查并等待它保存结果, 在关闭数据库之前调用。
+func (a *App) stopLinkCheck() {
+	a.linkCheckMutex.Lock()
+	cancel, done := a.linkCheckCancel, a.linkCheckDone
+	a.linkCheckMutex.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// GetPendingLinkReports 返回发件箱中尚未发送成功的反馈。
+func (a *App) GetPendingLinkReports() ([]models.LinkReport, error) {
+	return a.db.ListLinkReports(time.Time{})
+}
+
+const linkPolicySettingKey = "link_policy"
+
+// GetLinkPolicy 返回打开外部链接时使用的域名允许/禁止列表。
+func (a *App) GetLinkPolicy() (links.Policy, error) {
+	raw, ok, err := a.db.GetSetting(linkPolicySettingKey)
+	if err != nil {
+		return links.Policy{}, err
+	}
+	if !ok {
+		return links.Policy{Allow: []string{}, Deny: []string{}}, nil
+	}
+	var policy links.Policy
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return links.Policy{}, fmt.Errorf("解析链接策略失败: %w", err)
+	}
+	return policy.Normalize(), nil
+}
+
+func (a *App) SetLinkPolicy(policy links.Policy) error {
+	data, err := json.Marshal(policy.Normalize())
+	if err != nil {
+		return err
+	}
+	return a.db.SetSetting(linkPolicySettingKey, string(data))
+}
+
+// OpenExternal 校验协议和域名后用系统浏览器 (或磁力链接的关联程序) 打开链接。
+func (a *App) OpenExternal(rawURL string) error {
+	policy, err := a.GetLinkPolicy()
+	if err != nil {
+		return err
+	}
+	u, err := policy.Check(rawURL)
+	if err != nil {
+		log.Printf("外部链接：已拦截 %s: %v", rawURL, err)
+		return err
+	}
+	log.Printf("外部链接：打开 %s", u.Redacted())
+	runtime.BrowserOpenURL(a.ctx, u.String())
+	return nil
+}
+
+// CopyLink 把链接连同提取码和解压密码一起复制到剪贴板, 返回复制的文本。
+// 优先使用游戏下载信息中解析出的提取码, 找不到对应条目时按链接文本本身解析。
+func (a *App) CopyLink(gameID int64, linkURL string) (string, error) {
+	linkURL = strings.TrimSpace(linkURL)
+	if linkURL == "" {
+		return "", fmt.Errorf("链接不能为空")
+	}
+
+	text, target := linkURL, linkURL
+	if parsed := links.ParseText(linkURL); len(parsed) > 0 {
+		text, target = parsed[0].ShareText(), parsed[0].URL
+	}
+	if game, err := a.db.GetGameByID(gameID); err == nil {
+		for _, link := range links.ParseDownloadField(stringFromPtr(game.DownloadLink)) {
+			if link.URL == target && (link.Code != "" || link.Password != "") {
+				text = link.ShareText()
+				break
+			}
+		}
+	}
+
+	if err := runtime.ClipboardSetText(a.ctx, text); err != nil {
+		return "", fmt.Errorf("复制到剪贴板失败: %w", err)
+	}
+	return text, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"galgame-gui/internal/imagecache"
+)
+
+// PrefetchGames 把指定游戏的封面和全部预览图下载进图片缓存, 进度通过 prefetch-progress 事件推送。
+func (a *App) PrefetchGames(ids []int64) (imagecache.PrefetchStatus, error) {
+	if a.prefetcher == nil {
+		return imagecache.PrefetchStatus{}, fmt.Errorf("图片缓存不可用")
+	}
+	games, err := a.db.GetGamesByIDs(ids)
+	if err != nil {
+		return imagecache.PrefetchStatus{}, err
+	}
+
+	var urls []string
+	for _, game := range games {
+		urls = append(urls, stringFromPtr(game.CoverURL))
+		if game.PreviewURLs != nil {
+			urls = append(urls, strings.Split(*game.PreviewURLs, ",")...)
+		}
+	}
+	log.Printf("图片预取：为 %d 个游戏加入 %d 张图片", len(games), len(urls))
+	return a.prefetcher.Enqueue(urls), nil
+}
+
+// PrefetchUserLibrary 预取带有用户数据的游戏, status 为空时包括所有状态。
+func (a *App) PrefetchUserLibrary(status string) (imagecache.PrefetchStatus, error) {
+	ids, err := a.db.ListUserGameIDs(status)
+	if err != nil {
+		return imagecache.PrefetchStatus{}, err
+	}
+	return a.PrefetchGames(ids)
+}
+
+func (a *App) PausePrefetch() {
+	if a.prefetcher != nil {
+		a.prefetcher.Pause()
+	}
+}
+
+func (a *App) ResumePrefetch() {
+	if a.prefetcher != nil {
+		a.prefetcher.Resume()
+	}
+}
+
+func (a *App) CancelPrefetch() {
+	if a.prefetcher != nil {
+		a.prefetcher.Cancel()
+	}
+}
+
+// UnpinPrefetchedImages 取消预取图片的固定, 它们之后会和其它图片一样按 LRU 淘汰。
+func (a *App) UnpinPrefetchedImages() (imagecache.PrefetchStatus, error) {
+	if a.prefetcher == nil {
+		return imagecache.PrefetchStatus{}, fmt.Errorf("图片缓存不可用")
+	}
+	if err := a.images.UnpinAll(); err != nil {
+		return imagecache.PrefetchStatus{}, err
+	}
+	return a.prefetcher.Status(), nil
+}
+
+func (a *App) GetPrefetchStatus() imagecache.PrefetchStatus {
+	if a.prefetcher == nil {
+		return imagecache.PrefetchStatus{}
+	}
+	return a.prefetcher.Status()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"galgame-gui/internal/models"
+	ggsync "galgame-gui/internal/sync"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ListQuarantinedGames 返回同步时被拒绝的行, 最新的在前。
+func (a *App) ListQuarantinedGames() ([]models.QuarantinedGame, error) {
+	return a.db.ListQuarantinedGames()
+}
+
+// RetryQuarantinedGames 重新解析并写入指定的隔离行, 适用于修复了解析或校验规则之后。
+// 仍然失败的行会留在隔离区, 并出现在返回结果的 RowErrors 中。
+func (a *App) RetryQuarantinedGames(ids []int64) (ggsync.Report, error) {
+	a.syncMutex.Lock()
+	if a.isSyncing {
+		a.syncMutex.Unlock()
+		return ggsync.Report{}, fmt.Errorf("正在同步数据, 请在同步完成后再重试")
+	}
+	a.isSyncing = true
+	a.syncMutex.Unlock()
+	defer func() {
+		a.syncMutex.Lock()
+		a.isSyncing = false
+		a.syncMutex.Unlock()
+	}()
+
+	report := ggsync.Report{SyncID: ggsync.NewSyncID(), Fetched: len(ids)}
+	var upserted int
+	var rowErrors []models.RowError
+	err := ggsync.WithLease(a.db, func(ctx context.Context) error {
+		var err error
+		upserted, rowErrors, err = a.db.RetryQuarantined(ids, report.SyncID)
+		return err
+	})
+	if err != nil {
+		log.Printf("重试隔离数据失败: %v", err)
+		return ggsync.Report{}, err
+	}
+	report.Upserted = upserted
+	report.RowErrors = append([]models.RowError{}, rowErrors...)
+	log.Printf("隔离区：重试 %d 条, 成功 %d 条, 仍失败 %d 条", len(ids), upserted, len(rowErrors))
+
+	if upserted > 0 {
+		runtime.EventsEmit(a.ctx, "sync-report", report)
+	}
+	return report, nil
+}
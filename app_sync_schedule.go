@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	ggsync "galgame-gui/internal/sync"
+)
+
+// SyncSchedule 是定期同步的设置, IntervalMinutes 为 0 表示关闭。
+type SyncSchedule struct {
+	IntervalMinutes int        `json:"interval_minutes"`
+	LastSuccess     *time.Time `json:"last_success"`
+}
+
+// GetSyncSchedule 返回当前的同步间隔和上一次成功同步的时间。前端可能在启动完成、定时器创建之前调用,
+// 此时返回保存的设置。
+func (a *App) GetSyncSchedule() (SyncSchedule, error) {
+	var interval time.Duration
+	if a.syncScheduler != nil {
+		interval = a.syncScheduler.Interval()
+	} else {
+		var err error
+		if interval, err = ggsync.LoadInterval(a.db); err != nil {
+			return SyncSchedule{}, err
+		}
+	}
+	schedule := SyncSchedule{IntervalMinutes: int(interval.Minutes())}
+	last, ok, err := ggsync.LastSuccess(a.db)
+	if err != nil {
+		return schedule, err
+	}
+	if ok {
+		schedule.LastSuccess = &last
+	}
+	return schedule, nil
+}
+
+// SetSyncInterval 修改定期同步的间隔 (分钟), 0 表示关闭, 立即生效。
+func (a *App) SetSyncInterval(minutes int) error {
+	interval := time.Duration(minutes) * time.Minute
+	if err := ggsync.SaveInterval(a.db, interval); err != nil {
+		log.Printf("保存同步间隔失败: %v", err)
+		return err
+	}
+	// 定时器尚未创建时, 启动完成后会读取刚保存的间隔
+	if a.syncScheduler != nil {
+		a.syncScheduler.SetInterval(interval)
+	}
+	return nil
+}
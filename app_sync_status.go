@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"galgame-gui/internal/database"
+	"galgame-gui/internal/models"
+	ggsync "galgame-gui/internal/sync"
+)
+
+const (
+	defaultSyncHistoryLimit = 20
+	maxSyncHistoryLimit     = 200
+)
+
+// SyncStatus 是同步面板显示的状态。LastFailure 是最近一次失败的同步,
+// 早于 LastSuccess 时说明问题已经恢复。Lease 是当前持有同步锁的进程, 可能是另一个窗口。
+type SyncStatus struct {
+	Running     bool                `json:"running"`
+	Lease       *database.SyncLease `json:"lease"`
+	LastSuccess *time.Time          `json:"last_success"`
+	LastFailure *models.SyncRun     `json:"last_failure"`
+	LastRun     *models.SyncRun     `json:"last_run"`
+}
+
+func (a *App) GetSyncStatus() (SyncStatus, error) {
+	a.syncMutex.Lock()
+	status := SyncStatus{Running: a.isSyncing}
+	a.syncMutex.Unlock()
+
+	last, ok, err := ggsync.LastSuccess(a.db)
+	if err != nil {
+		return status, err
+	}
+	if ok {
+		status.LastSuccess = &last
+	}
+	if status.Lease, err = ggsync.LeaseHolder(a.db); err != nil {
+		return status, err
+	}
+	if status.LastFailure, err = a.db.LatestSyncRun(models.SyncStatusFailed); err != nil {
+		return status, err
+	}
+	runs, err := a.db.ListSyncRuns(1)
+	if err != nil {
+		return status, err
+	}
+	if len(runs) > 0 {
+		status.LastRun = &runs[0]
+	}
+	return status, nil
+}
+
+// GetSyncHistory 返回最近 limit 次同步, 最新的在前。limit 不大于 0 时返回最近 20 次。
+func (a *App) GetSyncHistory(limit int) ([]models.SyncRun, error) {
+	if limit <= 0 {
+		limit = defaultSyncHistoryLimit
+	}
+	if limit > maxSyncHistoryLimit {
+		limit = maxSyncHistoryLimit
+	}
+	return a.db.ListSyncRuns(limit)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"galgame-gui/internal/models"
+	ggsync "galgame-gui/internal/sync"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// UndoLastSync 把最近一次同步改动过的游戏恢复到同步前的状态, 并固定同步游标,
+// 避免下一次同步马上重新应用同一批数据。返回被撤销的同步。
+func (a *App) UndoLastSync() (models.SyncRun, error) {
+	a.syncMutex.Lock()
+	if a.isSyncing {
+		a.syncMutex.Unlock()
+		return models.SyncRun{}, fmt.Errorf("正在同步数据, 请在同步完成后再撤销")
+	}
+	a.isSyncing = true
+	a.syncMutex.Unlock()
+	defer func() {
+		a.syncMutex.Lock()
+		a.isSyncing = false
+		a.syncMutex.Unlock()
+	}()
+
+	run, err := ggsync.UndoLast(a.db)
+	if err != nil {
+		log.Printf("撤销同步失败: %v", err)
+		return models.SyncRun{}, err
+	}
+	log.Printf("数据同步：已撤销同步 %s (新增 %d, 更新 %d, 删除 %d)", run.ID, run.Added, run.Updated, run.Removed)
+	runtime.EventsEmit(a.ctx, "sync-undone", run)
+	return run, nil
+}
+
+// GetSyncPin 返回撤销同步后设置的游标固定, 没有时返回 nil。
+func (a *App) GetSyncPin() (*ggsync.Pin, error) {
+	return ggsync.LoadPin(a.db)
+}
+
+// ClearSyncPin 解除游标固定, 下一次同步会重新拉取被撤销的数据 (如果数据服务上仍未修正)。
+func (a *App) ClearSyncPin() error {
+	if err := ggsync.ClearPin(a.db); err != nil {
+		log.Printf("解除同步游标固定失败: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// mkseed 从一个已经同步过的数据库生成内置的目录快照, 在发布前运行:
+//
+//	go run ./cmd/mkseed -db ShiroGal.db -out internal/database/seed/catalog.json.gz
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"galgame-gui/internal/database"
+)
+
+func main() {
+	dbPath := flag.String("db", "ShiroGal.db", "已同步的数据库")
+	out := flag.String("out", "internal/database/seed/catalog.json.gz", "快照输出路径")
+	flag.Parse()
+
+	db, err := database.NewService(*dbPath)
+	if err != nil {
+		log.Fatalf("打开数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	seed, err := db.BuildSeed()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("创建快照文件失败: %v", err)
+	}
+	if err := database.WriteSeed(file, seed); err != nil {
+		file.Close()
+		log.Fatalf("%v", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("写入快照文件失败: %v", err)
+	}
+	log.Printf("已生成目录快照: %d 个游戏, 同步游标 %s", len(seed.Games), seed.Cursor.Format("2006-01-02 15:04:05"))
+}
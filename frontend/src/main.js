@@ -12,6 +12,16 @@ const CSS_CLASSES = {
 };
 const TRIGGER_ELEMENT_CLASS = 'infinite-scroll-trigger';
 
+// 后端保存的是原始文本, 拼进 HTML 之前必须转义, 属性值和文本内容都用这个函数。
+const HTML_ESCAPES = {'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;', '`': '&#96;'};
+
+function escapeHTML(value) {
+    return String(value ?? '').replace(/[&<>"'`]/g, (ch) => HTML_ESCAPES[ch]);
+}
+
+// 简介中的原始 HTML 按文本显示, 不交给浏览器解析
+marked.use({renderer: {html: ({text}) => escapeHTML(text)}});
+
 
 const dataCache = new Map();
 const detailCache = new Map();
@@ -138,7 +148,9 @@ function createGameElement(game) {
 }
 
 function renderGameElementContent(element, viewMode) {
-    const {title, brand, release, cover} = element.dataset;
+    const {title, brand, release, cover} = Object.fromEntries(
+        ['title', 'brand', 'release', 'cover'].map((key) => [key, escapeHTML(element.dataset[key])])
+    );
     element.className = viewMode === 'card' ? 'game-card card shadow-sm' : 'game-list-item';
     element.innerHTML = viewMode === 'card'
         ? `
@@ -290,7 +302,7 @@ async function loadGames(page = 1) {
     } catch (error) {
         console.error('加载游戏失败:', error);
         if (shouldReplaceContent) {
-            targetContainer.innerHTML = `<p class="text-center text-danger w-100 p-4">加载失败，请重试: ${error.message}</p>`;
+            targetContainer.innerHTML = `<p class="text-center text-danger w-100 p-4">加载失败，请重试: ${escapeHTML(error.message)}</p>`;
         }
         state.hasMore = false;
     } finally {
@@ -319,7 +331,7 @@ async function showDetailView(id) {
     } catch (error) {
         console.error('加载详情失败:', error);
         detailCache.delete(id);
-        content.innerHTML = `<p class="text-center text-danger p-5">加载详情失败，请重试: ${error.message}</p>`;
+        content.innerHTML = `<p class="text-center text-danger p-5">加载详情失败，请重试: ${escapeHTML(error.message)}</p>`;
     }
 }
 
@@ -329,25 +341,21 @@ function hideDetailView() {
 }
 
 function renderGameDetails(game) {
-    const releaseDate = game.release_date ? new Date(game.release_date).toLocaleDateString('zh-CN', {
-        year: 'numeric',
-        month: 'long',
-        day: 'numeric'
-    }) : '未知';
-    const displayTitle = game.title_cn || game.title_jp || '无标题';
-    const originalTitle = game.title_cn && game.title_jp ? `<h3 class="text-muted fw-light mb-4">${game.title_jp}</h3>` : '';
-    const coverUrl = game.cover_url || 'data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7';
+    const releaseDate = escapeHTML(game.release_date || '未知');
+    const displayTitle = escapeHTML(game.title_cn || game.title_jp || '无标题');
+    const originalTitle = game.title_cn && game.title_jp ? `<h3 class="text-muted fw-light mb-4">${escapeHTML(game.title_jp)}</h3>` : '';
+    const coverUrl = escapeHTML(game.cover_url || 'data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7');
     return `<div class="container-fluid"><div class="row g-4 py-4"><div class="col-lg-4"><div class="detail-cover-container"><img src="${coverUrl}" class="detail-cover mb-4" onerror="this.style.display='none'" draggable="false">${renderDetailInfoCard(game.brand, releaseDate)}</div></div><div class="col-lg-8"><div class="p-lg-3"><h1 class="display-6 fw-bold mb-1">${displayTitle}</h1>${originalTitle}<div id="detail-tags-container" class="mb-4 d-flex flex-wrap gap-2">${renderTags(game.tags)}</div><hr class="my-4">${renderSynopsis(game.synopsis)}${renderPreviews(game.preview_urls)}${renderDownloads(game.download_link)}</div></div></div></div>`;
 }
 
 function renderDetailInfoCard(brand, releaseDate) {
-    return `<div class="card info-card"><div class="card-body p-4"><h5 class="card-title mb-3">游戏信息</h5><ul class="list-unstyled mb-0"><li class="mb-2 d-flex align-items-center"><i class="bi bi-building fs-5 me-3 text-muted"></i><strong>${brand || '未知'}</strong></li><li class="d-flex align-items-center"><i class="bi bi-calendar-event fs-5 me-3 text-muted"></i><span>${releaseDate}</span></li></ul></div></div>`;
+    return `<div class="card info-card"><div class="card-body p-4"><h5 class="card-title mb-3">游戏信息</h5><ul class="list-unstyled mb-0"><li class="mb-2 d-flex align-items-center"><i class="bi bi-building fs-5 me-3 text-muted"></i><strong>${escapeHTML(brand || '未知')}</strong></li><li class="d-flex align-items-center"><i class="bi bi-calendar-event fs-5 me-3 text-muted"></i><span>${releaseDate}</span></li></ul></div></div>`;
 }
 
 function renderTags(tags) {
     if (!tags || !tags.trim()) return '<p class="text-muted mb-0">暂无标签</p>';
     return tags.split(',').map(tag => {
-        const trimmed = tag.trim();
+        const trimmed = escapeHTML(tag.trim());
         return trimmed ? `<a href="#" class="badge rounded-pill tag-badge text-decoration-none" data-tag="${trimmed}">${trimmed}</a>` : '';
     }).filter(Boolean).join('');
 }
@@ -359,7 +367,7 @@ function renderSynopsis(synopsis) {
 
 function renderPreviews(previews) {
     const content = previews && previews.trim() ? previews.split(',').map(url => {
-        const trimmed = url.trim();
+        const trimmed = escapeHTML(url.trim());
         return trimmed ? `<img src="${trimmed}" alt="预览图" loading="lazy" data-action="view-image" data-url="${trimmed}" draggable="false">` : '';
     }).filter(Boolean).join('') : '<p class="text-muted">暂无预览图</p>';
     return `<div class="card info-card mb-4"><div class="card-body"><h5 class="card-title">预览图</h5><div class="preview-gallery mt-3">${content}</div></div></div>`;
@@ -382,10 +390,10 @@ function parseAndRenderDownloads(downloadLinkText) {
             warning: '<i class="bi bi-exclamation-triangle-fill text-warning"></i>',
             invalid: '<i class="bi bi-x-circle-fill text-danger"></i>',
         }[link.status] || '';
-        const linkUrl = link.url || '#';
-        const infoParts = [link.size, link.platform, link.language, link.host].filter(Boolean);
+        const linkUrl = escapeHTML(link.url || '#');
+        const infoParts = [link.size, link.platform, link.language, link.host].filter(Boolean).map(escapeHTML);
         const infoHTML = infoParts.length ? `<small class="text-muted">${infoParts.join(' · ')}</small>` : '';
-        return `<div class="resource-item"><div class="resource-item-info"><strong class="me-2">${link.type || 'N/A'}</strong>${infoHTML}</div><div class="resource-item-actions"><button class="btn btn-sm btn-outline-secondary" data-action="copy-link" data-link="${linkUrl}"><i class="bi bi-clipboard"></i> 复制</button><a href="${linkUrl}" target="_blank" rel="noopener noreferrer" class="btn btn-sm btn-primary"><i class="bi bi-box-arrow-up-right"></i> 打开</a><span class="resource-item-status fs-5 d-flex align-items-center ms-2">${statusIcon}</span></div></div>`;
+        return `<div class="resource-item"><div class="resource-item-info"><strong class="me-2">${escapeHTML(link.type || 'N/A')}</strong>${infoHTML}</div><div class="resource-item-actions"><button class="btn btn-sm btn-outline-secondary" data-action="copy-link" data-link="${linkUrl}"><i class="bi bi-clipboard"></i> 复制</button><a href="${linkUrl}" target="_blank" rel="noopener noreferrer" class="btn btn-sm btn-primary"><i class="bi bi-box-arrow-up-right"></i> 打开</a><span class="resource-item-status fs-5 d-flex align-items-center ms-2">${statusIcon}</span></div></div>`;
     }).join('');
 }
 
@@ -5,6 +5,8 @@ go 1.24.5
 require (
 	github.com/mattn/go-sqlite3 v1.14.30
 	github.com/wailsapp/wails/v2 v2.10.2
+	golang.org/x/image v0.29.0
+	golang.org/x/text v0.27.0
 )
 
 require (
@@ -34,5 +36,4 @@ require (
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
-	golang.org/x/text v0.27.0 // indirect
 )
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"galgame-gui/internal/models"
@@ -42,8 +43,24 @@ func NewClient(baseURL string, publicKey string, privateKey string) *Client {
 	}
 }
 
-func (c *Client) newAuthenticatedRequest(method, urlStr string) (*http.Request, error) {
-	req, err := http.NewRequest(method, urlStr, nil)
+// StatusError 表示数据服务返回了非 2xx 的 HTTP 状态码。
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API返回HTTP状态 %d: %s", e.StatusCode, e.Body)
+}
+
+// Permanent 报告该错误是否重试也无法成功 (除超时和限流以外的 4xx)。
+func (e *StatusError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+func (c *Client) newAuthenticatedRequest(method, urlStr string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, urlStr, body)
 	if err != nil {
 		return nil, err
 	}
@@ -51,20 +68,28 @@ func (c *Client) newAuthenticatedRequest(method, urlStr string) (*http.Request,
 	return req, nil
 }
 
-func (c *Client) GetUpdates(since time.Time) ([]models.Galgame, error) {
+// RejectedRow 是一行无法解析为游戏数据的原始记录。
+type RejectedRow struct {
+	GameID int64
+	Raw    string
+	Err    error
+}
+
+// GetUpdates 返回 since 之后更新的游戏, 以及无法解析的行。
+func (c *Client) GetUpdates(since time.Time) ([]models.Galgame, []RejectedRow, error) {
 	fullURL, _ := url.Parse(c.BaseURL + "/games/updates")
 	queryParams := fullURL.Query()
 	queryParams.Set("since", since.Format(time.RFC3339))
 	fullURL.RawQuery = queryParams.Encode()
 
-	req, err := c.newAuthenticatedRequest("GET", fullURL.String())
+	req, err := c.newAuthenticatedRequest("GET", fullURL.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	res, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("执行API请求失败: %w", err)
+		return nil, nil, fmt.Errorf("执行API请求失败: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -75,34 +100,51 @@ func (c *Client) GetUpdates(since time.Time) ([]models.Galgame, error) {
 
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取API响应失败: %w", err)
+		return nil, nil, fmt.Errorf("读取API响应失败: %w", err)
 	}
 
 	var apiResponse TidbDataServiceResponse
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
 		log.Printf("无法解析API响应外层JSON，原始响应: %s", string(body))
-		return nil, fmt.Errorf("解析API响应外层JSON失败: %w", err)
+		return nil, nil, fmt.Errorf("解析API响应外层JSON失败: %w", err)
 	}
 
 	resultCode, _ := strconv.Atoi(apiResponse.Data.Result.Code.String())
 	if resultCode != 200 {
 		log.Printf("API返回了非200的内部代码。完整响应: %s", string(body))
-		return nil, fmt.Errorf("API返回错误代码 %d: %s", resultCode, apiResponse.Data.Result.Message)
+		return nil, nil, fmt.Errorf("API返回错误代码 %d: %s", resultCode, apiResponse.Data.Result.Message)
 	}
 
-	var games []models.Galgame
-	if err := json.Unmarshal(apiResponse.Data.Rows, &games); err != nil {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(apiResponse.Data.Rows, &rows); err != nil {
 		log.Printf("解析games数组失败，原始rows数据: %s", string(apiResponse.Data.Rows))
-		return nil, fmt.Errorf("解析游戏更新列表JSON失败: %w", err)
+		return nil, nil, fmt.Errorf("解析游戏更新列表JSON失败: %w", err)
 	}
 
-	return games, nil
+	// 逐行解析, 单行数据异常时只拒绝这一行, 由调用方放入隔离区
+	var games []models.Galgame
+	var rejected []RejectedRow
+	for _, row := range rows {
+		var game models.Galgame
+		if err := json.Unmarshal(row, &game); err != nil {
+			var idOnly struct {
+				ID json.Number `json:"id"`
+			}
+			_ = json.Unmarshal(row, &idOnly)
+			id, _ := idOnly.ID.Int64()
+			rejected = append(rejected, RejectedRow{GameID: id, Raw: string(row), Err: err})
+			continue
+		}
+		games = append(games, game)
+	}
+
+	return games, rejected, nil
 }
 
 func (c *Client) GetAllActiveIDs() ([]int64, error) {
 	fullURL := c.BaseURL + "/games/ids"
 
-	req, err := c.newAuthenticatedRequest("GET", fullURL)
+	req, err := c.newAuthenticatedRequest("GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建获取所有ID的请求失败: %w", err)
 	}
@@ -155,3 +197,54 @@ func (c *Client) GetAllActiveIDs() ([]int64, error) {
 
 	return ids, nil
 }
+
+// ReportLink 把一条失效链接反馈提交到数据服务。
+func (c *Client) ReportLink(report models.LinkReport) error {
+	payload, err := json.Marshal(struct {
+		GameID     int64  `json:"game_id"`
+		URL        string `json:"url"`
+		Reason     string `json:"reason"`
+		ReportedAt string `json:"reported_at"`
+	}{report.GameID, report.URL, report.Reason, report.CreatedAt.UTC().Format(time.RFC3339)})
+	if err != nil {
+		return fmt.Errorf("编码链接反馈失败: %w", err)
+	}
+
+	req, err := c.newAuthenticatedRequest("POST", c.BaseURL+"/links/reports", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建链接反馈请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("执行API请求提交链接反馈失败: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+
+		}
+	}(res.Body)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("读取API响应失败: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusError{StatusCode: res.StatusCode, Body: string(body)}
+	}
+
+	var apiResponse TidbDataServiceResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		log.Printf("无法解析API响应外层JSON，原始响应: %s", string(body))
+		return fmt.Errorf("解析API响应外层JSON失败: %w", err)
+	}
+
+	resultCode, _ := strconv.Atoi(apiResponse.Data.Result.Code.String())
+	if resultCode != 200 {
+		log.Printf("API返回了非200的内部代码。完整响应: %s", string(body))
+		return &StatusError{StatusCode: resultCode, Body: apiResponse.Data.Result.Message}
+	}
+	return nil
+}
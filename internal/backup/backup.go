@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"fmt"
+	"galgame-gui/internal/database"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInterval = 24 * time.Hour
+	DefaultKeep     = 7
+)
+
+type Info struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// Manager 定期为数据库生成快照, 并按种类分别只保留最近 Keep 份。
+// 迁移前的备份由数据库在打开时生成, 在启动和恢复之后统一清理。
+type Manager struct {
+	db       *database.Service
+	dir      string
+	interval time.Duration
+	keep     int
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewManager(db *database.Service, interval time.Duration, keep int) *Manager {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	return &Manager{
+		db:       db,
+		dir:      database.BackupDir(db.Path()),
+		interval: interval,
+		keep:     keep,
+	}
+}
+
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.loop(m.stop, m.done)
+}
+
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (m *Manager) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	if err := m.rotate(database.BackupKindPreMigrate); err != nil {
+		log.Printf("备份：清理旧的迁移前备份失败: %v", err)
+	}
+
+	// 启动时如果距离上一次自动备份已经超过间隔, 立即补做一次
+	var wait time.Duration
+	if last, ok := m.latest(database.BackupKindAuto); ok && time.Since(last.CreatedAt) < m.interval {
+		wait = m.interval - time.Since(last.CreatedAt)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			if _, err := m.Snapshot(database.BackupKindAuto); err != nil {
+				log.Printf("自动备份：备份失败: %v", err)
+			}
+			timer.Reset(m.interval)
+		}
+	}
+}
+
+func (m *Manager) Snapshot(kind string) (Info, error) {
+	now := time.Now()
+	path := filepath.Join(m.dir, database.BackupFileName(kind, now))
+	if err := m.db.BackupTo(path); err != nil {
+		return Info{}, err
+	}
+	log.Printf("备份：已生成 %s", filepath.Base(path))
+
+	if err := m.rotate(kind); err != nil {
+		log.Printf("备份：清理旧备份失败: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Name: info.Name(), Kind: kind, CreatedAt: now.Truncate(time.Second), Size: info.Size()}, nil
+}
+
+// List 按时间从新到旧返回所有备份。
+func (m *Manager) List() ([]Info, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Info{}, nil
+		}
+		return nil, fmt.Errorf("读取备份目录失败: %w", err)
+	}
+
+	backups := []Info{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		kind, createdAt, ok := database.ParseBackupFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{Name: entry.Name(), Kind: kind, CreatedAt: createdAt, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Restore 先为当前数据库做一次 prerestore 快照, 再把指定备份恢复到正在运行的数据库中。
+func (m *Manager) Restore(name string) error {
+	if _, _, ok := database.ParseBackupFileName(name); !ok || filepath.Base(name) != name {
+		return fmt.Errorf("无效的备份文件名: %s", name)
+	}
+	path := filepath.Join(m.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("找不到备份文件 %s: %w", name, err)
+	}
+
+	if _, err := m.Snapshot(database.BackupKindPreRestore); err != nil {
+		return fmt.Errorf("恢复前备份当前数据库失败: %w", err)
+	}
+	if err := m.db.RestoreFrom(path); err != nil {
+		return err
+	}
+	log.Printf("备份：已从 %s 恢复数据库", name)
+
+	// 恢复旧版本的备份会触发迁移并生成新的迁移前备份
+	if err := m.rotate(database.BackupKindPreMigrate); err != nil {
+		log.Printf("备份：清理旧的迁移前备份失败: %v", err)
+	}
+	return nil
+}
+
+func (m *Manager) latest(kind string) (Info, bool) {
+	backups, err := m.List()
+	if err != nil {
+		return Info{}, false
+	}
+	for _, b := range backups {
+		if b.Kind == kind {
+			return b, true
+		}
+	}
+	return Info{}, false
+}
+
+func (m *Manager) rotate(kind string) error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, b := range backups {
+		if b.Kind != kind {
+			continue
+		}
+		kept++
+		if kept <= m.keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.dir, b.Name)); err != nil {
+			return err
+		}
+		log.Printf("备份：已删除过期备份 %s", b.Name)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"database/sql"
+	"galgame-gui/internal/database"
+	"galgame-gui/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, keep int) (*database.Service, *Manager) {
+	t.Helper()
+	db, err := database.NewService(filepath.Join(t.TempDir(), "ShiroGal.db"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db, NewManager(db, time.Hour, keep)
+}
+
+func gameTitles(t *testing.T, db *database.Service) map[int64]string {
+	t.Helper()
+	games, err := db.ListGames("")
+	if err != nil {
+		t.Fatalf("ListGames: %v", err)
+	}
+	titles := make(map[int64]string)
+	for _, g := range games {
+		titles[g.ID] = g.TitleJP
+	}
+	return titles
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	db, m := newTestManager(t, DefaultKeep)
+	if _, _, err := db.UpsertGames([]models.Galgame{{ID: 1, TitleJP: "一"}}, "first"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := m.Snapshot(database.BackupKindManual)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	if _, _, err := db.UpsertGames([]models.Galgame{{ID: 1, TitleJP: "一改"}, {ID: 2, TitleJP: "二"}}, "second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Restore(snapshot.Name); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got := gameTitles(t, db); len(got) != 1 || got[1] != "一" {
+		t.Errorf("games after restore = %v, want only game 1 with its old title", got)
+	}
+	if _, ok := m.latest(database.BackupKindPreRestore); !ok {
+		t.Error("Restore did not snapshot the current database first")
+	}
+}
+
+func TestRestoreUpgradesOlderSchema(t *testing.T) {
+	db, m := newTestManager(t, DefaultKeep)
+
+	// 只有最初的 games 表、user_version 为 0 的旧版本数据库
+	name := database.BackupFileName(database.BackupKindManual, time.Now().Add(-time.Hour))
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old, err := sql.Open("sqlite3", filepath.Join(m.dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE games (
+            id INTEGER PRIMARY KEY,
+            title_jp TEXT NOT NULL,
+            title_cn TEXT,
+            brand TEXT,
+            release_date DATETIME,
+            synopsis TEXT,
+            cover_url TEXT,
+            preview_urls TEXT,
+            tags TEXT,
+            download_link TEXT,
+            created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now')),
+            updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+        );`,
+		`INSERT INTO games (id, title_jp) VALUES (7, '旧版本的游戏');`,
+	} {
+		if _, err := old.Exec(statement); err != nil {
+			t.Fatalf("build old backup: %v", err)
+		}
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Restore(name); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got := gameTitles(t, db); len(got) != 1 || got[7] != "旧版本的游戏" {
+		t.Errorf("games after restore = %v, want the game from the old backup", got)
+	}
+	fresh, err := database.NewService(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if got, want := schemaVersion(t, db), schemaVersion(t, fresh); got != want {
+		t.Errorf("schema version after restore = %d, want %d", got, want)
+	}
+	// 恢复后的数据库能正常使用后续迁移加入的表
+	if err := db.QueueLinkReport(7, "https://pan.baidu.com/s/1a", ""); err != nil {
+		t.Errorf("QueueLinkReport after restore: %v", err)
+	}
+	if _, ok := m.latest(database.BackupKindPreMigrate); !ok {
+		t.Error("upgrading the restored database did not write a premigrate backup")
+	}
+}
+
+func schemaVersion(t *testing.T, db *database.Service) int {
+	t.Helper()
+	rows, err := db.Query(`PRAGMA user_version;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+	var version int
+	if !rows.Next() {
+		t.Fatal("PRAGMA user_version returned no rows")
+	}
+	if err := rows.Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestStartRotatesPreMigrateBackups(t *testing.T) {
+	_, m := newTestManager(t, 2)
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-24 * time.Hour)
+	var names []string
+	for i := 0; i < 4; i++ {
+		name := database.BackupFileName(database.BackupKindPreMigrate, base.Add(time.Duration(i)*time.Minute))
+		if err := os.WriteFile(filepath.Join(m.dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	m.Start()
+	m.Stop()
+
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(m.dir, name))
+		if kept := err == nil; kept != (i >= 2) {
+			t.Errorf("%s kept = %v, want only the newest 2", name, kept)
+		}
+	}
+}
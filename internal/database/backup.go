@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	BackupKindAuto       = "auto"
+	BackupKindManual     = "manual"
+	BackupKindPreMigrate = "premigrate"
+	BackupKindPreRestore = "prerestore"
+
+	backupTimeLayout = "20060102-150405"
+)
+
+func BackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+func BackupPath(dbPath string, kind string, t time.Time) string {
+	return filepath.Join(BackupDir(dbPath), BackupFileName(kind, t))
+}
+
+func BackupFileName(kind string, t time.Time) string {
+	return fmt.Sprintf("ShiroGal-%s-%s.db", kind, t.Format(backupTimeLayout))
+}
+
+// ParseBackupFileName 是 BackupFileName 的逆操作, 不符合命名规则的文件返回 false。
+func ParseBackupFileName(name string) (kind string, createdAt time.Time, ok bool) {
+	var stamp string
+	for _, k := range []string{BackupKindAuto, BackupKindManual, BackupKindPreMigrate, BackupKindPreRestore} {
+		prefix := "ShiroGal-" + k + "-"
+		if len(name) == len(prefix)+len(backupTimeLayout)+len(".db") && name[:len(prefix)] == prefix {
+			kind = k
+			stamp = name[len(prefix) : len(name)-len(".db")]
+			break
+		}
+	}
+	if kind == "" {
+		return "", time.Time{}, false
+	}
+	createdAt, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return kind, createdAt, true
+}
+
+func (s *Service) Path() string {
+	return s.path
+}
+
+// BackupTo 用 VACUUM INTO 生成一致的数据库快照, 运行期间也可以安全调用。
+func (s *Service) BackupTo(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("备份文件已存在: %s", path)
+	}
+	if _, err := s.db.Exec(`VACUUM INTO ?;`, path); err != nil {
+		return fmt.Errorf("备份数据库失败: %w", err)
+	}
+	return nil
+}
+
+// RestoreFrom 通过 SQLite 在线备份 API 把备份文件的内容整体复制进正在使用的数据库,
+// 不需要关闭或替换数据库文件, 其它连接在复制完成后即可看到恢复的数据。
+func (s *Service) RestoreFrom(path string) error {
+	src, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("打开备份文件失败: %w", err)
+	}
+	defer src.Close()
+
+	var integrity string
+	if err := src.QueryRow(`PRAGMA quick_check;`).Scan(&integrity); err != nil {
+		return fmt.Errorf("检查备份文件失败: %w", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("备份文件已损坏: %s", integrity)
+	}
+
+	ctx := context.Background()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("连接备份文件失败: %w", err)
+	}
+	defer srcConn.Close()
+
+	destConn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %w", err)
+	}
+	defer destConn.Close()
+
+	err = destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("不支持的数据库驱动连接: %T", destDriverConn)
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("不支持的数据库驱动连接: %T", srcDriverConn)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("恢复数据库失败: %w", err)
+	}
+
+	// 备份可能来自旧版本, 恢复后需要补齐迁移并重建视图
+	return s.prepareSchema()
+}
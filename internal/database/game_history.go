@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+	"time"
+)
+
+// game_history 由 UpsertGames 在同一个事务中写入, 每个变化的字段一行。
+// 首次写入的游戏记录所有非空字段, old_value 为 NULL。
+const createGameHistoryTableQuery = `
+    CREATE TABLE IF NOT EXISTS game_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        game_id INTEGER NOT NULL,
+        sync_id TEXT NOT NULL DEFAULT '',
+        field TEXT NOT NULL,
+        old_value TEXT,
+        new_value TEXT,
+        changed_at DATETIME NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_game_history_game ON game_history(game_id, changed_at);`
+
+// previousSyncedGame 在写入前读取 games 表中的当前版本, 游戏尚不存在时 created 为 true。
+func previousSyncedGame(tx *sql.Tx, id int64) (old models.Galgame, created bool, err error) {
+	old, err = scanSyncedGame(tx.QueryRow(selectSyncedGameColumns+` WHERE id = ?;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Galgame{}, true, nil
+	}
+	return old, false, err
+}
+
+// recordGameHistory 比较旧版本和已写入的新版本, 并记录变化的字段。
+func recordGameHistory(tx *sql.Tx, syncID string, old models.Galgame, created bool, game models.Galgame, changedAt time.Time) error {
+	var fields []models.FieldChange
+	if created {
+		for _, value := range models.GameFieldValues(game) {
+			if value.New != "" {
+				fields = append(fields, value)
+			}
+		}
+	} else {
+		fields = models.DiffGames(old, game)
+	}
+
+	for _, field := range fields {
+		var oldValue interface{}
+		if !created {
+			oldValue = field.Old
+		}
+		_, err := tx.Exec(`
+            INSERT INTO game_history (game_id, sync_id, field, old_value, new_value, changed_at)
+            VALUES (?, ?, ?, ?, ?, ?);`,
+			game.ID, syncID, field.Field, oldValue, field.New, changedAt.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGameHistory 按时间从新到旧返回游戏的变更记录, 同一次写入的字段合并为一项。
+func (s *Service) GetGameHistory(gameID int64) ([]models.GameHistoryEntry, error) {
+	rows, err := s.db.Query(`
+        SELECT sync_id, field, old_value IS NULL, COALESCE(old_value, ''), COALESCE(new_value, ''), changed_at
+        FROM game_history WHERE game_id = ?
+        ORDER BY changed_at DESC, id;`, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("查询游戏历史失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	history := []models.GameHistoryEntry{}
+	for rows.Next() {
+		var syncID string
+		var created bool
+		var field models.FieldChange
+		var changedAt time.Time
+		if err := rows.Scan(&syncID, &field.Field, &created, &field.Old, &field.New, &changedAt); err != nil {
+			log.Printf("扫描游戏历史失败: %v", err)
+			continue
+		}
+		if n := len(history); n > 0 && history[n-1].SyncID == syncID && history[n-1].ChangedAt.Equal(changedAt) {
+			history[n-1].Fields = append(history[n-1].Fields, field)
+			continue
+		}
+		history = append(history, models.GameHistoryEntry{
+			GameID:    gameID,
+			SyncID:    syncID,
+			ChangedAt: changedAt,
+			Created:   created,
+			Fields:    []models.FieldChange{field},
+		})
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+const createImageMetaTableQuery = `
+    CREATE TABLE IF NOT EXISTS image_meta (
+        url TEXT PRIMARY KEY,
+        blurhash TEXT NOT NULL,
+        dominant_color TEXT NOT NULL,
+        accent_color TEXT NOT NULL,
+        updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+    );`
+
+func (s *Service) SaveImageMeta(url string, blurhash string, dominantColor string, accentColor string) error {
+	query := `
+        INSERT INTO image_meta (url, blurhash, dominant_color, accent_color)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT(url) DO UPDATE SET
+            blurhash=excluded.blurhash,
+            dominant_color=excluded.dominant_color,
+            accent_color=excluded.accent_color,
+            updated_at=strftime('%Y-%m-%d %H:%M:%S', 'now');`
+	if _, err := s.db.Exec(query, url, blurhash, dominantColor, accentColor); err != nil {
+		return fmt.Errorf("保存图片占位信息失败: %w", err)
+	}
+	return nil
+}
+
+// IsCoverURL 报告 url 是否是某个游戏的封面。每张图片进入缓存时都会调用, 因此直接在带索引的
+// games、local_games 和 game_overrides 上查找, 而不是扫描 games_merged 视图;
+// 被覆盖掉的原封面仍算作封面, 这只会多算一份占位信息。
+func (s *Service) IsCoverURL(url string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM games WHERE cover_url = ?)
+            OR EXISTS (SELECT 1 FROM local_games WHERE cover_url = ?)
+            OR EXISTS (SELECT 1 FROM game_overrides WHERE field = 'cover_url' AND value = ?);`,
+		url, url, url,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("查询封面地址失败: %w", err)
+	}
+	return exists, nil
+}
+
+func (s *Service) ListCoverURLsWithoutMeta() ([]string, error) {
+	rows, err := s.db.Query(`
+        SELECT DISTINCT cover_url FROM games_merged
+        WHERE cover_url IS NOT NULL AND cover_url <> '' AND cover_blurhash IS NULL;`)
+	if err != nil {
+		return nil, fmt.Errorf("查询缺少占位信息的封面失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			log.Printf("扫描封面地址失败: %v", err)
+			continue
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
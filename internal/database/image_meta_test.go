@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"galgame-gui/internal/models"
+)
+
+func TestIsCoverURL(t *testing.T) {
+	s := newTestService(t)
+	synced, local, override := "https://img/synced.jpg", "https://img/local.jpg", "https://img/override.jpg"
+	if _, _, err := s.UpsertGames([]models.Galgame{{ID: 1, TitleJP: "一", CoverURL: &synced}}, "sync"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddLocalGame(models.Galgame{TitleJP: "本地", CoverURL: &local}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetOverride(1, "tags", override); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{synced, true},
+		{local, true},
+		// 只有 cover_url 字段的覆盖值才算封面
+		{override, false},
+		{"https://img/preview.jpg", false},
+	}
+	for _, tt := range tests {
+		got, err := s.IsCoverURL(tt.url)
+		if err != nil {
+			t.Fatalf("IsCoverURL(%q): %v", tt.url, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsCoverURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if err := s.SetOverride(1, "cover_url", override); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.IsCoverURL(override); err != nil || !got {
+		t.Errorf("IsCoverURL(override cover) = %v, %v, want true", got, err)
+	}
+}
+
+func TestIsCoverURLUsesIndexes(t *testing.T) {
+	s := newTestService(t)
+	rows, err := s.db.Query(`
+        EXPLAIN QUERY PLAN
+        SELECT EXISTS (SELECT 1 FROM games WHERE cover_url = ?)
+            OR EXISTS (SELECT 1 FROM local_games WHERE cover_url = ?)
+            OR EXISTS (SELECT 1 FROM game_overrides WHERE field = 'cover_url' AND value = ?);`, "x", "x", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		// 外层 SELECT 没有 FROM, 计划中显示为 SCAN CONSTANT ROW
+		if strings.HasPrefix(detail, "SCAN") && detail != "SCAN CONSTANT ROW" {
+			t.Errorf("query plan scans a table: %s", detail)
+		}
+	}
+}
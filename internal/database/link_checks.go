@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+)
+
+const createLinkChecksTableQuery = `
+    CREATE TABLE IF NOT EXISTS link_checks (
+        game_id INTEGER NOT NULL,
+        url TEXT NOT NULL,
+        status TEXT NOT NULL,
+        detail TEXT NOT NULL DEFAULT '',
+        http_status INTEGER NOT NULL DEFAULT 0,
+        checked_at DATETIME NOT NULL,
+        PRIMARY KEY (game_id, url)
+    );`
+
+func (s *Service) SaveLinkChecks(checks []models.LinkCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+        INSERT INTO link_checks (game_id, url, status, detail, http_status, checked_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT(game_id, url) DO UPDATE SET
+            status=excluded.status,
+            detail=excluded.detail,
+            http_status=excluded.http_status,
+            checked_at=excluded.checked_at;`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func(stmt *sql.Stmt) {
+		err := stmt.Close()
+		if err != nil {
+
+		}
+	}(stmt)
+
+	for _, c := range checks {
+		if _, err := stmt.Exec(c.GameID, c.URL, c.Status, c.Detail, c.HTTPStatus, c.CheckedAt.UTC()); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("保存链接检查结果失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Service) GetLinkChecks(gameID int64) ([]models.LinkCheck, error) {
+	rows, err := s.db.Query(`
+        SELECT game_id, url, status, detail, http_status, checked_at
+        FROM link_checks WHERE game_id = ? ORDER BY url;`, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("查询链接检查结果失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	var checks []models.LinkCheck
+	for rows.Next() {
+		var c models.LinkCheck
+		if err := rows.Scan(&c.GameID, &c.URL, &c.Status, &c.Detail, &c.HTTPStatus, &c.CheckedAt); err != nil {
+			log.Printf("扫描链接检查结果失败: %v", err)
+			continue
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
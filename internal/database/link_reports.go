@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+	"time"
+)
+
+// link_reports 是失效链接反馈的发件箱: 发送成功后删除, 失败时记录错误并推迟下次尝试。
+// 同一游戏的同一链接只保留一条待发送的反馈, 重复提交时更新原因。
+const createLinkReportsTableQuery = `
+    CREATE TABLE IF NOT EXISTS link_reports (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        game_id INTEGER NOT NULL,
+        url TEXT NOT NULL,
+        reason TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        attempts INTEGER NOT NULL DEFAULT 0,
+        last_error TEXT NOT NULL DEFAULT '',
+        next_attempt_at DATETIME NOT NULL,
+        UNIQUE (game_id, url)
+    );`
+
+func (s *Service) QueueLinkReport(gameID int64, url string, reason string) error {
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`
+        INSERT INTO link_reports (game_id, url, reason, created_at, next_attempt_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT(game_id, url) DO UPDATE SET
+            reason=excluded.reason,
+            next_attempt_at=CASE WHEN sending_by = '' THEN excluded.next_attempt_at ELSE next_attempt_at END;`,
+		gameID, url, reason, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("保存链接反馈失败: %w", err)
+	}
+	return nil
+}
+
+// ListLinkReports 返回发件箱中的反馈; dueBefore 非零时只返回到期需要发送的。
+func (s *Service) ListLinkReports(dueBefore time.Time) ([]models.LinkReport, error) {
+	query := `SELECT id, game_id, url, reason, created_at, attempts, last_error, next_attempt_at FROM link_reports`
+	var args []interface{}
+	if !dueBefore.IsZero() {
+		query += ` WHERE next_attempt_at <= ?`
+		args = append(args, dueBefore.UTC())
+	}
+	query += ` ORDER BY next_attempt_at, id;`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询链接反馈失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	reports := []models.LinkReport{}
+	for rows.Next() {
+		var r models.LinkReport
+		if err := rows.Scan(&r.ID, &r.GameID, &r.URL, &r.Reason, &r.CreatedAt, &r.Attempts, &r.LastError, &r.NextAttemptAt); err != nil {
+			log.Printf("扫描链接反馈失败: %v", err)
+			continue
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// ClaimLinkReports 原子地领取最多 limit 条到期的反馈并返回它们: 领取的行记录 claim 并把下次尝试时间
+// 推迟到 until, 其他进程在此之前不会再领取到, 本进程异常退出时到期后会被重新领取。
+// claim 每次领取都应不同, 以免取回之前领取后未处理完的行。
+func (s *Service) ClaimLinkReports(claim string, now time.Time, until time.Time, limit int) ([]models.LinkReport, error) {
+	_, err := s.db.Exec(`
+        UPDATE link_reports SET sending_by = ?, next_attempt_at = ?
+        WHERE id IN (
+            SELECT id FROM link_reports WHERE next_attempt_at <= ?
+            ORDER BY next_attempt_at, id LIMIT ?
+        );`,
+		claim, until.UTC(), now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("领取链接反馈失败: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+        SELECT id, game_id, url, reason, created_at, attempts, last_error, next_attempt_at
+        FROM link_reports WHERE sending_by = ? ORDER BY id;`, claim)
+	if err != nil {
+		return nil, fmt.Errorf("查询链接反馈失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	reports := []models.LinkReport{}
+	for rows.Next() {
+		var r models.LinkReport
+		if err := rows.Scan(&r.ID, &r.GameID, &r.URL, &r.Reason, &r.CreatedAt, &r.Attempts, &r.LastError, &r.NextAttemptAt); err != nil {
+			log.Printf("扫描链接反馈失败: %v", err)
+			continue
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+func (s *Service) DeleteLinkReport(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM link_reports WHERE id = ?;`, id)
+	return err
+}
+
+func (s *Service) MarkLinkReportFailed(id int64, lastError string, nextAttempt time.Time) error {
+	_, err := s.db.Exec(`
+        UPDATE link_reports SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, sending_by = ''
+        WHERE id = ?;`,
+		lastError, nextAttempt.UTC(), id,
+	)
+	return err
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"galgame-gui/internal/models"
+	"strconv"
+	"strings"
+)
+
+// 本地游戏使用负数ID, 与远端目录的正数ID互不冲突, 同步流程也不会触碰 local_games 表。
+const createLocalGamesTableQuery = `
+    CREATE TABLE IF NOT EXISTS local_games (
+        id INTEGER PRIMARY KEY CHECK (id < 0),
+        title_jp TEXT NOT NULL,
+        title_cn TEXT,
+        brand TEXT,
+        release_date DATETIME,
+        synopsis TEXT,
+        cover_url TEXT,
+        preview_urls TEXT,
+        tags TEXT,
+        download_link TEXT,
+        created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now')),
+        updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+    );`
+
+const createLocalGamesTriggerQuery = `
+    CREATE TRIGGER IF NOT EXISTS update_local_games_updated_at
+    AFTER UPDATE ON local_games
+    FOR EACH ROW
+    BEGIN
+        UPDATE local_games SET updated_at = strftime('%Y-%m-%d %H:%M:%S', 'now') WHERE id = OLD.id;
+    END;`
+
+// localGameIDSettingKey 记录最近分配的本地游戏ID。ID 只减不增, 删除的游戏的ID不会再被分配。
+const localGameIDSettingKey = "local_game_last_id"
+
+func IsLocalGameID(id int64) bool {
+	return id < 0
+}
+
+func (s *Service) AddLocalGame(game models.Galgame) (int64, error) {
+	if strings.TrimSpace(game.TitleJP) == "" {
+		return 0, fmt.Errorf("游戏标题不能为空")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// 旧版本没有记录分配过的ID, 同时参考现有的最小ID
+	var id int64
+	err = tx.QueryRow(`
+        SELECT MIN(
+            COALESCE((SELECT CAST(value AS INTEGER) FROM settings WHERE key = ?), 0),
+            COALESCE((SELECT MIN(id) FROM local_games), 0)
+        ) - 1;`, localGameIDSettingKey).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("分配本地游戏ID失败: %w", err)
+	}
+	if err = setSetting(tx, localGameIDSettingKey, strconv.FormatInt(id, 10)); err != nil {
+		return 0, fmt.Errorf("分配本地游戏ID失败: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO local_games (id, title_jp, title_cn, brand, release_date, release_precision, synopsis, cover_url, preview_urls, tags, download_link)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		id, game.TitleJP, game.TitleCN, game.Brand, game.ReleaseDate.SortKey(), game.ReleaseDate.Precision,
+		game.Synopsis, game.CoverURL, game.PreviewURLs, game.Tags, game.DownloadLink,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("添加本地游戏失败: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *Service) EditLocalGame(id int64, game models.Galgame) error {
+	if !IsLocalGameID(id) {
+		return fmt.Errorf("ID为 %d 的游戏不是本地游戏, 无法编辑", id)
+	}
+	if strings.TrimSpace(game.TitleJP) == "" {
+		return fmt.Errorf("游戏标题不能为空")
+	}
+
+	res, err := s.db.Exec(`
+        UPDATE local_games SET
+            title_jp = ?, title_cn = ?, brand = ?, release_date = ?, release_precision = ?, synopsis = ?,
+            cover_url = ?, preview_urls = ?, tags = ?, download_link = ?
+        WHERE id = ?;`,
+		game.TitleJP, game.TitleCN, game.Brand, game.ReleaseDate.SortKey(), game.ReleaseDate.Precision,
+		game.Synopsis, game.CoverURL, game.PreviewURLs, game.Tags, game.DownloadLink, id,
+	)
+	if err != nil {
+		return fmt.Errorf("编辑本地游戏失败: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("未找到ID为 %d 的本地游戏", id)
+	}
+	return nil
+}
+
+// localGameDependentTables 是以 game_id 关联游戏的表, 删除本地游戏时一并删除这些行。
+// 旧版本会重新分配删除过的ID, 遗留的行会被新添加的游戏继承, 由迁移统一清理。
+var localGameDependentTables = []string{"user_games", "game_overrides", "link_checks", "link_reports", "game_history"}
+
+func (s *Service) DeleteLocalGame(id int64) error {
+	if !IsLocalGameID(id) {
+		return fmt.Errorf("ID为 %d 的游戏不是本地游戏, 无法删除", id)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.Exec(`DELETE FROM local_games WHERE id = ?;`, id)
+	if err != nil {
+		return fmt.Errorf("删除本地游戏失败: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		err = fmt.Errorf("未找到ID为 %d 的本地游戏", id)
+		return err
+	}
+
+	for _, table := range localGameDependentTables {
+		if _, err = tx.Exec(`DELETE FROM `+table+` WHERE game_id = ?;`, id); err != nil {
+			return fmt.Errorf("删除本地游戏的关联数据失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteOrphanedLocalGameData 删除已不存在的本地游戏留下的关联数据。
+func deleteOrphanedLocalGameData(tx *sql.Tx) error {
+	for _, table := range localGameDependentTables {
+		_, err := tx.Exec(`DELETE FROM ` + table + ` WHERE game_id < 0 AND game_id NOT IN (SELECT id FROM local_games);`)
+		if err != nil {
+			return fmt.Errorf("清理 %s 中已删除本地游戏的数据失败: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"galgame-gui/internal/models"
+)
+
+func countGameRows(t *testing.T, s *Service, id int64) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	for _, table := range localGameDependentTables {
+		var n int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE game_id = ?;`, id).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n > 0 {
+			counts[table] = n
+		}
+	}
+	return counts
+}
+
+func addLocalGameData(t *testing.T, s *Service, id int64) {
+	t.Helper()
+	if err := s.SetUserGame(models.UserGame{GameID: id, Status: models.UserStatusPlaying, Rating: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO game_overrides (game_id, field, value) VALUES (?, 'title_cn', '旧译名');`, id); err != nil {
+		t.Fatal(err)
+	}
+	check := models.LinkCheck{GameID: id, URL: "https://example.com/a", Status: "ok", CheckedAt: time.Now()}
+	if err := s.SaveLinkChecks([]models.LinkCheck{check}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.QueueLinkReport(id, "https://example.com/a", "失效"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO game_history (game_id, field, new_value, changed_at) VALUES (?, 'title_jp', '旧游戏', ?);`, id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteLocalGameRemovesDependentRows(t *testing.T) {
+	s := newTestService(t)
+	id, err := s.AddLocalGame(models.Galgame{TitleJP: "旧游戏"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addLocalGameData(t, s, id)
+
+	if err := s.DeleteLocalGame(id); err != nil {
+		t.Fatalf("DeleteLocalGame: %v", err)
+	}
+	if counts := countGameRows(t, s, id); len(counts) > 0 {
+		t.Errorf("rows left after delete: %v", counts)
+	}
+
+	// 删除的ID不会再分配给新游戏
+	next, err := s.AddLocalGame(models.Galgame{TitleJP: "新游戏"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next >= id {
+		t.Errorf("new local game got ID %d, want one below the deleted %d", next, id)
+	}
+	if counts := countGameRows(t, s, next); len(counts) > 0 {
+		t.Errorf("new local game %d inherited rows: %v", next, counts)
+	}
+
+	if err := s.DeleteLocalGame(-100); err == nil {
+		t.Error("deleting a missing local game should fail")
+	}
+}
+
+func TestDeleteOrphanedLocalGameData(t *testing.T) {
+	s := newTestService(t)
+	kept, err := s.AddLocalGame(models.Galgame{TitleJP: "保留"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addLocalGameData(t, s, kept)
+	// 模拟旧版本删除本地游戏后留下的数据
+	const orphan = -42
+	addLocalGameData(t, s, orphan)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteOrphanedLocalGameData(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if counts := countGameRows(t, s, orphan); len(counts) > 0 {
+		t.Errorf("orphaned rows left: %v", counts)
+	}
+	if counts := countGameRows(t, s, kept); len(counts) != len(localGameDependentTables) {
+		t.Errorf("rows of existing local game = %v, want one per table", counts)
+	}
+}
+
+func TestAddLocalGameNeverReusesIDs(t *testing.T) {
+	s := newTestService(t)
+	// 旧版本分配的ID没有记录在配置中
+	if _, err := s.db.Exec(`INSERT INTO local_games (id, title_jp) VALUES (-5, '旧版本添加');`); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.AddLocalGame(models.Galgame{TitleJP: "一"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != -6 {
+		t.Errorf("first ID = %d, want -6 below the existing games", first)
+	}
+	for _, id := range []int64{first, -5} {
+		if err := s.DeleteLocalGame(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	second, err := s.AddLocalGame(models.Galgame{TitleJP: "二"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != -7 {
+		t.Errorf("ID after deleting every local game = %d, want -7", second)
+	}
+}
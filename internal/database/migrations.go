@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type migration struct {
+	version    int
+	name       string
+	statements []string
+	// apply 在 statements 之后、同一个事务中执行, 用于无法用 SQL 表达的数据迁移
+	apply func(tx *sql.Tx) error
+}
+
+// migrations 按版本号递增排列, 已应用的版本记录在 PRAGMA user_version 中。
+// 新的表结构变更只能追加在末尾, 不能修改已经发布的迁移。
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "字段覆盖、本地游戏与用户数据",
+		statements: []string{
+			createOverridesTableQuery,
+			createLocalGamesTableQuery,
+			createLocalGamesTriggerQuery,
+			createUserGamesTableQuery,
+		},
+	},
+	{
+		version:    2,
+		name:       "封面占位信息",
+		statements: []string{createImageMetaTableQuery},
+	},
+	{
+		version:    3,
+		name:       "下载链接检查结果",
+		statements: []string{createLinkChecksTableQuery},
+	},
+	{
+		version:    4,
+		name:       "失效链接反馈发件箱",
+		statements: []string{createLinkReportsTableQuery},
+	},
+	{
+		version:    5,
+		name:       "应用配置",
+		statements: []string{createSettingsTableQuery},
+	},
+	{
+		version:    6,
+		name:       "同步数据隔离区",
+		statements: []string{createQuarantineTableQuery},
+	},
+	{
+		version: 7,
+		name:    "隔离区记录同步批次和失败阶段",
+		statements: []string{
+			`ALTER TABLE quarantined_games ADD COLUMN sync_id TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE quarantined_games ADD COLUMN stage TEXT NOT NULL DEFAULT 'validate';`,
+		},
+	},
+	{
+		// release_date 从此保存排序键 (模糊日期取时间段的最后一天), 精度另存一列。
+		// 旧数据都精确到日, 缺失日期时同步写入的是零值时间。
+		version: 8,
+		name:    "发售日期精度",
+		statements: []string{
+			`ALTER TABLE games ADD COLUMN release_precision TEXT NOT NULL DEFAULT 'day';`,
+			`ALTER TABLE local_games ADD COLUMN release_precision TEXT NOT NULL DEFAULT 'day';`,
+			`UPDATE games SET release_precision = '' WHERE release_date IS NULL OR release_date < '1000';`,
+			`UPDATE local_games SET release_precision = '' WHERE release_date IS NULL OR release_date < '1000';`,
+		},
+	},
+	{
+		version:    9,
+		name:       "同步变更记录",
+		statements: []string{createSyncRunsTableQuery, createSyncChangesTableQuery},
+	},
+	{
+		version:    10,
+		name:       "游戏字段历史",
+		statements: []string{createGameHistoryTableQuery},
+	},
+	{
+		version: 11,
+		name:    "撤销同步",
+		statements: []string{
+			createSyncSnapshotsTableQuery,
+			`ALTER TABLE sync_runs ADD COLUMN has_snapshot INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE sync_runs ADD COLUMN undone_at DATETIME;`,
+		},
+	},
+	{
+		version: 12,
+		name:    "同步状态与各阶段计数",
+		statements: []string{
+			// 之前只有成功的同步才会留下记录
+			`ALTER TABLE sync_runs ADD COLUMN status TEXT NOT NULL DEFAULT 'succeeded';`,
+			`ALTER TABLE sync_runs ADD COLUMN error TEXT;`,
+			`ALTER TABLE sync_runs ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE sync_runs ADD COLUMN fetched INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE sync_runs ADD COLUMN upserted INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE sync_runs ADD COLUMN rejected INTEGER NOT NULL DEFAULT 0;`,
+			`CREATE INDEX IF NOT EXISTS idx_sync_runs_started ON sync_runs(started_at);`,
+		},
+	},
+	{
+		version:    13,
+		name:       "跨进程同步锁",
+		statements: []string{createSyncLeaseTableQuery},
+	},
+	{
+		version: 14,
+		name:    "按新的清理规则重新处理已有数据",
+		apply:   resanitizeStoredData,
+	},
+	{
+		version: 15,
+		name:    "清理已删除本地游戏的关联数据",
+		apply:   deleteOrphanedLocalGameData,
+	},
+	{
+		version:    16,
+		name:       "发件箱记录正在发送的进程",
+		statements: []string{`ALTER TABLE link_reports ADD COLUMN sending_by TEXT NOT NULL DEFAULT '';`},
+	},
+	{
+		version: 17,
+		name:    "按封面地址查找游戏的索引",
+		statements: []string{
+			`CREATE INDEX IF NOT EXISTS idx_games_cover_url ON games(cover_url);`,
+			`CREATE INDEX IF NOT EXISTS idx_local_games_cover_url ON local_games(cover_url);`,
+			`CREATE INDEX IF NOT EXISTS idx_game_overrides_value ON game_overrides(field, value);`,
+		},
+	},
+	{
+		version: 18,
+		name:    "清理已删除本地游戏的反馈和字段历史",
+		apply:   deleteOrphanedLocalGameData,
+	},
+}
+
+func (s *Service) schemaVersion() (int, error) {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version;`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	return version, nil
+}
+
+func (s *Service) migrate() error {
+	current, err := s.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var gameCount int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM games;`).Scan(&gameCount); err != nil {
+		return fmt.Errorf("统计游戏数量失败: %w", err)
+	}
+	if current > 0 || gameCount > 0 {
+		backupPath := BackupPath(s.path, BackupKindPreMigrate, time.Now())
+		if err := s.BackupTo(backupPath); err != nil {
+			return fmt.Errorf("迁移前备份数据库失败: %w", err)
+		}
+		log.Printf("数据库迁移：已在迁移前备份到 %s", backupPath)
+	}
+
+	for _, m := range pending {
+		if err := s.applyMigration(m); err != nil {
+			return fmt.Errorf("应用迁移 v%d (%s) 失败: %w", m.version, m.name, err)
+		}
+		log.Printf("数据库迁移：已应用 v%d (%s)", m.version, m.name)
+	}
+	return nil
+}
+
+func (s *Service) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range m.statements {
+		if _, err := tx.Exec(statement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if m.apply != nil {
+		if err := m.apply(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d;`, m.version)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OverridableFields 列出允许用户在本地覆盖的字段, 值为 games 表中的列名。
+var OverridableFields = map[string]struct{}{
+	"title_jp":  {},
+	"title_cn":  {},
+	"brand":     {},
+	"synopsis":  {},
+	"cover_url": {},
+	"tags":      {},
+}
+
+const createOverridesTableQuery = `
+    CREATE TABLE IF NOT EXISTS game_overrides (
+        game_id INTEGER NOT NULL,
+        field TEXT NOT NULL,
+        value TEXT NOT NULL,
+        updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now')),
+        PRIMARY KEY (game_id, field)
+    );`
+
+// games_merged 在读取时把 game_overrides 合并到同步下来的 games 上, 并并入用户手动添加的 local_games,
+// 同步的 ON CONFLICT DO UPDATE 只会改写 games, 因此覆盖值和本地游戏都不会丢失。
+const createMergedViewQuery = `
+    CREATE VIEW games_merged AS
+    SELECT
+        g.id AS id,
+        COALESCE(o.title_jp, g.title_jp) AS title_jp,
+        COALESCE(o.title_cn, g.title_cn) AS title_cn,
+        COALESCE(o.brand, g.brand) AS brand,
+        g.release_date AS release_date,
+        g.release_precision AS release_precision,
+        COALESCE(o.synopsis, g.synopsis) AS synopsis,
+        COALESCE(o.cover_url, g.cover_url) AS cover_url,
+        g.preview_urls AS preview_urls,
+        COALESCE(o.tags, g.tags) AS tags,
+        g.download_link AS download_link,
+        g.created_at AS created_at,
+        g.updated_at AS updated_at,
+        o.fields AS overridden_fields,
+        g.is_local AS is_local,
+        m.blurhash AS cover_blurhash,
+        m.dominant_color AS cover_dominant_color,
+        m.accent_color AS cover_accent_color
+    FROM (
+        SELECT id, title_jp, title_cn, brand, release_date, release_precision, synopsis, cover_url, preview_urls,
+               tags, download_link, created_at, updated_at, 0 AS is_local
+        FROM games
+        UNION ALL
+        SELECT id, title_jp, title_cn, brand, release_date, release_precision, synopsis, cover_url, preview_urls,
+               tags, download_link, created_at, updated_at, 1 AS is_local
+        FROM local_games
+    ) g
+    LEFT JOIN (
+        SELECT
+            game_id,
+            MAX(CASE field WHEN 'title_jp' THEN value END) AS title_jp,
+            MAX(CASE field WHEN 'title_cn' THEN value END) AS title_cn,
+            MAX(CASE field WHEN 'brand' THEN value END) AS brand,
+            MAX(CASE field WHEN 'synopsis' THEN value END) AS synopsis,
+            MAX(CASE field WHEN 'cover_url' THEN value END) AS cover_url,
+            MAX(CASE field WHEN 'tags' THEN value END) AS tags,
+            GROUP_CONCAT(field) AS fields
+        FROM game_overrides
+        GROUP BY game_id
+    ) o ON o.game_id = g.id
+    LEFT JOIN image_meta m ON m.url = COALESCE(o.cover_url, g.cover_url);`
+
+func SplitOverriddenFields(fields string) []string {
+	if fields == "" {
+		return nil
+	}
+	parts := strings.Split(fields, ",")
+	sort.Strings(parts)
+	return parts
+}
+
+func (s *Service) SetOverride(gameID int64, field string, value string) error {
+	if _, ok := OverridableFields[field]; !ok {
+		return fmt.Errorf("字段 '%s' 不支持覆盖", field)
+	}
+
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM games WHERE id = ?;`, gameID).Scan(&exists); err != nil {
+		return fmt.Errorf("查询游戏失败: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("未找到ID为 %d 的游戏", gameID)
+	}
+
+	query := `
+        INSERT INTO game_overrides (game_id, field, value)
+        VALUES (?, ?, ?)
+        ON CONFLICT(game_id, field) DO UPDATE SET
+            value=excluded.value,
+            updated_at=strftime('%Y-%m-%d %H:%M:%S', 'now');`
+	if _, err := s.db.Exec(query, gameID, field, value); err != nil {
+		return fmt.Errorf("保存字段覆盖失败: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) ClearOverride(gameID int64, field string) error {
+	if _, ok := OverridableFields[field]; !ok {
+		return fmt.Errorf("字段 '%s' 不支持覆盖", field)
+	}
+	if _, err := s.db.Exec(`DELETE FROM game_overrides WHERE game_id = ? AND field = ?;`, gameID, field); err != nil {
+		return fmt.Errorf("清除字段覆盖失败: %w", err)
+	}
+	return nil
+}
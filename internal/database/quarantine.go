@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+	"strings"
+)
+
+// quarantined_games 保存解析、校验或写入失败、因而没有进入 games 表的同步数据, 以便排查后重试。
+const createQuarantineTableQuery = `
+    CREATE TABLE IF NOT EXISTS quarantined_games (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        game_id INTEGER,
+        raw TEXT NOT NULL,
+        error TEXT NOT NULL,
+        quarantined_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+    );`
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertQuarantine 记录一行被拒绝的数据。同步游标不会因为被拒绝的行前进, 同一行可能被反复拉取,
+// 因此先移除内容相同的旧记录, 只保留最近一次。
+func insertQuarantine(e execer, syncID string, stage string, gameID int64, raw string, reason string) error {
+	if _, err := e.Exec(`DELETE FROM quarantined_games WHERE game_id = ? AND raw = ?;`, gameID, raw); err != nil {
+		return err
+	}
+	_, err := e.Exec(`
+        INSERT INTO quarantined_games (game_id, raw, error, sync_id, stage)
+        VALUES (?, ?, ?, ?, ?);`,
+		gameID, raw, reason, syncID, stage,
+	)
+	return err
+}
+
+// quarantineGame 隔离一行已经解析过的数据, 保存的是重新编码的 Galgame。
+func quarantineGame(tx *sql.Tx, syncID string, stage string, game models.Galgame, reason error) error {
+	raw, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("编码隔离数据失败: %w", err)
+	}
+	return insertQuarantine(tx, syncID, stage, game.ID, string(raw), reason.Error())
+}
+
+// QuarantineRaw 隔离一行无法解析的原始数据。
+func (s *Service) QuarantineRaw(syncID string, gameID int64, raw string, reason error) error {
+	if err := insertQuarantine(s.db, syncID, models.QuarantineStageDecode, gameID, raw, reason.Error()); err != nil {
+		return fmt.Errorf("隔离游戏ID %d 失败: %w", gameID, err)
+	}
+	return nil
+}
+
+func (s *Service) ListQuarantinedGames() ([]models.QuarantinedGame, error) {
+	return s.queryQuarantined("")
+}
+
+func (s *Service) queryQuarantined(whereClause string, args ...interface{}) ([]models.QuarantinedGame, error) {
+	rows, err := s.db.Query(`
+        SELECT id, COALESCE(game_id, 0), sync_id, stage, raw, error, quarantined_at
+        FROM quarantined_games `+whereClause+` ORDER BY id DESC;`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询隔离数据失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	result := []models.QuarantinedGame{}
+	for rows.Next() {
+		var q models.QuarantinedGame
+		if err := rows.Scan(&q.ID, &q.GameID, &q.SyncID, &q.Stage, &q.Raw, &q.Error, &q.QuarantinedAt); err != nil {
+			log.Printf("扫描隔离数据失败: %v", err)
+			continue
+		}
+		result = append(result, q)
+	}
+	return result, rows.Err()
+}
+
+// RetryQuarantined 重新解析并写入指定的隔离行。成功的行从隔离区移除;
+// 仍然失败的行会以新的原因重新隔离, 并作为逐行错误返回。
+// 游戏在隔离之后已经有新的版本写入时, 隔离行已经过时, 直接移除而不写入。
+// 重试的行沿用原来的时间戳写入, 不会移动同步游标。
+func (s *Service) RetryQuarantined(ids []int64, syncID string) (int, []models.RowError, error) {
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	entries, err := s.queryQuarantined(fmt.Sprintf("WHERE id IN (%s)", placeholders), args...)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var games []models.Galgame
+	var rowErrors []models.RowError
+	stale := 0
+	for _, entry := range entries {
+		newer, err := s.hasNewerSyncedGame(entry)
+		if err != nil {
+			return 0, nil, err
+		}
+		if newer {
+			stale++
+			continue
+		}
+		var game models.Galgame
+		if err := json.Unmarshal([]byte(entry.Raw), &game); err != nil {
+			rowErrors = append(rowErrors, models.RowError{GameID: entry.GameID, Stage: models.QuarantineStageDecode, Error: err.Error()})
+			if err := insertQuarantine(s.db, syncID, models.QuarantineStageDecode, entry.GameID, entry.Raw, err.Error()); err != nil {
+				log.Printf("隔离游戏ID %d 失败: %v", entry.GameID, err)
+			}
+			continue
+		}
+		games = append(games, game)
+	}
+
+	if stale > 0 {
+		log.Printf("隔离区：%d 条隔离数据之后已有新版本写入, 不再重试", stale)
+	}
+
+	count, upsertErrors, err := s.upsertGames(games, syncID, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := s.db.Exec(fmt.Sprintf(`DELETE FROM quarantined_games WHERE id IN (%s);`, placeholders), args...); err != nil {
+		return 0, nil, fmt.Errorf("移除隔离数据失败: %w", err)
+	}
+	return count, append(rowErrors, upsertErrors...), nil
+}
+
+// hasNewerSyncedGame 判断隔离行对应的游戏在隔离之后是否又被写入过。
+func (s *Service) hasNewerSyncedGame(entry models.QuarantinedGame) (bool, error) {
+	if entry.GameID == 0 {
+		return false, nil
+	}
+	var newer bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM games WHERE id = ? AND updated_at > ?);`,
+		entry.GameID, entry.QuarantinedAt.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&newer)
+	if err != nil {
+		return false, fmt.Errorf("检查游戏ID %d 的当前版本失败: %w", entry.GameID, err)
+	}
+	return newer, nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"galgame-gui/internal/models"
+)
+
+func quarantineAt(t *testing.T, s *Service, game models.Galgame, at string) int64 {
+	t.Helper()
+	raw, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := insertQuarantine(s.db, "old-sync", models.QuarantineStageValidate, game.ID, string(raw), "旧规则拒绝"); err != nil {
+		t.Fatal(err)
+	}
+	var id int64
+	if err := s.db.QueryRow(`SELECT MAX(id) FROM quarantined_games;`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`UPDATE quarantined_games SET quarantined_at = ? WHERE id = ?;`, at, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRetryQuarantinedKeepsCursorAndSkipsStaleRows(t *testing.T) {
+	s := newTestService(t)
+	const cursor = "2025-03-01 00:00:00"
+	for _, row := range []struct {
+		id        int64
+		title     string
+		updatedAt string
+	}{
+		{1, "一", "2025-01-01 00:00:00"},
+		{2, "二 (服务端新版本)", cursor},
+	} {
+		if _, err := s.db.Exec(`INSERT INTO games (id, title_jp, created_at, updated_at) VALUES (?, ?, ?, ?);`,
+			row.id, row.title, row.updatedAt, row.updatedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := []int64{
+		// 游戏1在隔离之后没有新版本, 应当写入
+		quarantineAt(t, s, models.Galgame{ID: 1, TitleJP: "一 (重试)"}, "2025-02-01 00:00:00"),
+		// 游戏2在隔离之后又同步过, 隔离行已经过时
+		quarantineAt(t, s, models.Galgame{ID: 2, TitleJP: "二 (旧)"}, "2025-02-01 00:00:00"),
+		// 游戏3是新游戏
+		quarantineAt(t, s, models.Galgame{ID: 3, TitleJP: "三"}, "2025-02-01 00:00:00"),
+	}
+
+	count, rowErrors, err := s.RetryQuarantined(ids, "retry")
+	if err != nil {
+		t.Fatalf("RetryQuarantined: %v", err)
+	}
+	if count != 2 || len(rowErrors) != 0 {
+		t.Errorf("count = %d, rowErrors = %+v, want 2 and none", count, rowErrors)
+	}
+
+	latest, err := s.GetLatestTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := time.Parse("2006-01-02 15:04:05", cursor); !latest.Equal(want) {
+		t.Errorf("cursor moved to %v, want %v", latest, want)
+	}
+
+	games, err := s.GetSyncedGames([]int64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]string{1: "一 (重试)", 2: "二 (服务端新版本)", 3: "三"}
+	for id, title := range want {
+		if got := games[id].TitleJP; got != title {
+			t.Errorf("game %d title = %q, want %q", id, got, title)
+		}
+	}
+
+	quarantined, err := s.ListQuarantinedGames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 0 {
+		t.Errorf("quarantine still holds %+v", quarantined)
+	}
+}
+
+func TestAcceptedSyncClearsQuarantine(t *testing.T) {
+	s := newTestService(t)
+	quarantineAt(t, s, models.Galgame{ID: 7, TitleJP: "旧"}, "2025-01-01 00:00:00")
+	quarantineAt(t, s, models.Galgame{ID: 8, TitleJP: "其它"}, "2025-01-01 00:00:00")
+
+	if _, _, err := s.UpsertGames([]models.Galgame{{ID: 7, TitleJP: "新"}}, "sync"); err != nil {
+		t.Fatal(err)
+	}
+	quarantined, err := s.ListQuarantinedGames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 || quarantined[0].GameID != 8 {
+		t.Errorf("quarantine = %+v, want only game 8", quarantined)
+	}
+}
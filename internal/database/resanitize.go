@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"galgame-gui/internal/models"
+	"galgame-gui/internal/sanitize"
+	"log"
+	"reflect"
+)
+
+const resanitizeSyncID = "migration-resanitize"
+
+// resanitizeStoredData 用当前的清理规则重新处理已经写入的数据。清理规则收紧之后,
+// 旧版本写入的行不会再经过 UpsertGames, 需要在迁移中补做一次:
+// 同步数据中不合格的行移入隔离区 (可以在修正规则后重试), 覆盖值删除, 本地游戏清空不合格的字段。
+func resanitizeStoredData(tx *sql.Tx) error {
+	if err := resanitizeGames(tx); err != nil {
+		return err
+	}
+	if err := resanitizeOverrides(tx); err != nil {
+		return err
+	}
+	return resanitizeLocalGames(tx)
+}
+
+func resanitizeGames(tx *sql.Tx) error {
+	rows, err := tx.Query(selectSyncedGameColumns + `;`)
+	if err != nil {
+		return fmt.Errorf("读取同步数据失败: %w", err)
+	}
+	var games []models.Galgame
+	for rows.Next() {
+		game, err := scanSyncedGame(rows)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描同步数据失败: %w", err)
+		}
+		games = append(games, game)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	cleanedCount, quarantined := 0, 0
+	for _, game := range games {
+		cleaned, err := sanitize.Game(game)
+		if err != nil {
+			if err := quarantineGame(tx, resanitizeSyncID, models.QuarantineStageValidate, game, err); err != nil {
+				return fmt.Errorf("隔离游戏ID %d 失败: %w", game.ID, err)
+			}
+			if _, err := tx.Exec(`DELETE FROM games WHERE id = ?;`, game.ID); err != nil {
+				return fmt.Errorf("删除游戏ID %d 失败: %w", game.ID, err)
+			}
+			quarantined++
+			continue
+		}
+		if reflect.DeepEqual(models.GameFieldValues(cleaned), models.GameFieldValues(game)) {
+			continue
+		}
+		if err := replaceSyncedGame(tx, cleaned); err != nil {
+			return fmt.Errorf("更新游戏ID %d 失败: %w", game.ID, err)
+		}
+		cleanedCount++
+	}
+	if cleanedCount > 0 || quarantined > 0 {
+		log.Printf("数据库迁移：重新清理同步数据, 修改 %d 条, 隔离 %d 条", cleanedCount, quarantined)
+	}
+	return nil
+}
+
+func resanitizeOverrides(tx *sql.Tx) error {
+	type override struct {
+		gameID int64
+		field  string
+		value  string
+	}
+	rows, err := tx.Query(`SELECT game_id, field, value FROM game_overrides;`)
+	if err != nil {
+		return fmt.Errorf("读取字段覆盖失败: %w", err)
+	}
+	var overrides []override
+	for rows.Next() {
+		var o override
+		if err := rows.Scan(&o.gameID, &o.field, &o.value); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描字段覆盖失败: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range overrides {
+		cleaned, err := sanitize.Field(o.field, o.value)
+		switch {
+		case err != nil:
+			log.Printf("数据库迁移：游戏ID %d 的 %s 覆盖值不合格, 已删除: %v", o.gameID, o.field, err)
+			_, err = tx.Exec(`DELETE FROM game_overrides WHERE game_id = ? AND field = ?;`, o.gameID, o.field)
+		case cleaned != o.value:
+			_, err = tx.Exec(`UPDATE game_overrides SET value = ? WHERE game_id = ? AND field = ?;`, cleaned, o.gameID, o.field)
+		}
+		if err != nil {
+			return fmt.Errorf("更新游戏ID %d 的字段覆盖失败: %w", o.gameID, err)
+		}
+	}
+	return nil
+}
+
+var localGameTextColumns = []string{"title_jp", "title_cn", "brand", "synopsis", "cover_url", "preview_urls", "tags", "download_link"}
+
+func resanitizeLocalGames(tx *sql.Tx) error {
+	type cell struct {
+		id     int64
+		column string
+		value  string
+	}
+	var cells []cell
+	for _, column := range localGameTextColumns {
+		rows, err := tx.Query(fmt.Sprintf(`SELECT id, %s FROM local_games WHERE %s IS NOT NULL;`, column, column))
+		if err != nil {
+			return fmt.Errorf("读取本地游戏失败: %w", err)
+		}
+		for rows.Next() {
+			c := cell{column: column}
+			if err := rows.Scan(&c.id, &c.value); err != nil {
+				rows.Close()
+				return fmt.Errorf("扫描本地游戏失败: %w", err)
+			}
+			cells = append(cells, c)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cells {
+		var value interface{}
+		cleaned, err := sanitize.Field(c.column, c.value)
+		switch {
+		case err == nil:
+			if cleaned == c.value {
+				continue
+			}
+			value = cleaned
+		case c.column == "title_jp":
+			// 标题不能为空, 只能保留按普通文本清理后的结果
+			value = sanitize.Text(c.value)
+		default:
+			log.Printf("数据库迁移：本地游戏ID %d 的 %s 不合格, 已清空: %v", c.id, c.column, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE local_games SET %s = ? WHERE id = ?;`, c.column), value, c.id); err != nil {
+			return fmt.Errorf("更新本地游戏ID %d 失败: %w", c.id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	s, err := NewService(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestResanitizeStoredData(t *testing.T) {
+	s := newTestService(t)
+	const updatedAt = "2025-01-02 03:04:05"
+	mustExec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := s.db.Exec(query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	mustExec(`INSERT INTO games (id, title_jp, synopsis, updated_at) VALUES (1, ?, 'ok', ?);`, `<i>x</i> "it's"`, updatedAt)
+	mustExec(`INSERT INTO games (id, title_jp, synopsis, updated_at) VALUES (2, 'bad', '[a](javascript:alert(1))', ?);`, updatedAt)
+	mustExec(`INSERT INTO games (id, title_jp, updated_at) VALUES (3, 'clean', ?);`, updatedAt)
+	mustExec(`INSERT INTO game_overrides (game_id, field, value) VALUES (3, 'synopsis', '[a](data:text/html,x)');`)
+	mustExec(`INSERT INTO game_overrides (game_id, field, value) VALUES (3, 'title_cn', 'it''s');`)
+	mustExec(`INSERT INTO local_games (id, title_jp, synopsis) VALUES (-1, '<b>本地</b>', '[a](javascript:x)');`)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resanitizeStoredData(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatalf("resanitizeStoredData: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	games, err := s.GetSyncedGames([]int64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只去掉标签, 引号原样保留
+	if got := games[1].TitleJP; got != `x "it's"` {
+		t.Errorf("game 1 title = %q", got)
+	}
+	if want, _ := time.Parse("2006-01-02 15:04:05", updatedAt); !games[1].UpdatedAt.Equal(want) {
+		t.Errorf("game 1 updated_at = %v, want %v (cursor must not move)", games[1].UpdatedAt, want)
+	}
+	if _, ok := games[2]; ok {
+		t.Error("game 2 should have been moved to quarantine")
+	}
+	quarantined, err := s.ListQuarantinedGames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 || quarantined[0].GameID != 2 {
+		t.Errorf("quarantined = %+v, want game 2", quarantined)
+	}
+
+	overrides := map[string]string{}
+	rows, err := s.db.Query(`SELECT field, value FROM game_overrides WHERE game_id = 3;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var field, value string
+		if err := rows.Scan(&field, &value); err != nil {
+			t.Fatal(err)
+		}
+		overrides[field] = value
+	}
+	rows.Close()
+	if _, ok := overrides["synopsis"]; ok {
+		t.Error("unsafe synopsis override should have been removed")
+	}
+	if got := overrides["title_cn"]; got != "it's" {
+		t.Errorf("title_cn override = %q", got)
+	}
+
+	var title string
+	var synopsis *string
+	if err := s.db.QueryRow(`SELECT title_jp, synopsis FROM local_games WHERE id = -1;`).Scan(&title, &synopsis); err != nil {
+		t.Fatal(err)
+	}
+	if title != "本地" || synopsis != nil {
+		t.Errorf("local game = %q, %v", title, synopsis)
+	}
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/models"
+	"galgame-gui/internal/sanitize"
+	"io"
+	"io/fs"
+	"log"
+	"time"
+)
+
+//go:embed seed
+var seedFS embed.FS
+
+const (
+	embeddedSeedPath = "seed/catalog.json.gz"
+	seedSettingKey   = "seed_snapshot"
+	// seedSyncID 标记导入快照时被隔离的行
+	seedSyncID = "seed"
+)
+
+// Seed 是随程序发布的目录快照。Cursor 是生成快照时的同步游标,
+// 导入后第一次同步只会拉取在它之后更新的游戏。
+type Seed struct {
+	BuiltAt time.Time        `json:"built_at"`
+	Cursor  time.Time        `json:"cursor"`
+	Games   []models.Galgame `json:"games"`
+}
+
+// SeedInfo 记录导入过的快照, 保存在设置中, 每个数据库只会自动导入一次。
+type SeedInfo struct {
+	BuiltAt     time.Time `json:"built_at"`
+	Cursor      time.Time `json:"cursor"`
+	Games       int       `json:"games"`
+	Quarantined int       `json:"quarantined"`
+	ImportedAt  time.Time `json:"imported_at"`
+}
+
+// WriteSeed 以 gzip 压缩的 JSON 输出快照。
+func WriteSeed(w io.Writer, seed Seed) error {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(zw).Encode(seed); err != nil {
+		zw.Close()
+		return fmt.Errorf("写入目录快照失败: %w", err)
+	}
+	return zw.Close()
+}
+
+func ReadSeed(r io.Reader) (Seed, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return Seed{}, fmt.Errorf("解压目录快照失败: %w", err)
+	}
+	defer zr.Close()
+
+	var seed Seed
+	if err := json.NewDecoder(zr).Decode(&seed); err != nil {
+		return Seed{}, fmt.Errorf("解析目录快照失败: %w", err)
+	}
+	return seed, nil
+}
+
+// BuildSeed 用 games 表中同步下来的数据生成快照, 不包含用户的覆盖值和本地游戏。
+func (s *Service) BuildSeed() (Seed, error) {
+	cursor, err := s.GetLatestTimestamp()
+	if err != nil {
+		return Seed{}, fmt.Errorf("读取同步游标失败: %w", err)
+	}
+	rows, err := s.db.Query(selectSyncedGameColumns + ` ORDER BY id;`)
+	if err != nil {
+		return Seed{}, fmt.Errorf("查询同步数据失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	seed := Seed{BuiltAt: time.Now().UTC(), Cursor: cursor}
+	for rows.Next() {
+		game, err := scanSyncedGame(rows)
+		if err != nil {
+			return Seed{}, fmt.Errorf("扫描同步数据失败: %w", err)
+		}
+		seed.Games = append(seed.Games, game)
+	}
+	return seed, rows.Err()
+}
+
+// importEmbeddedSeed 在全新的数据库上导入内置快照。程序没有内置快照、数据库中已经有游戏,
+// 或者以前导入过时不做任何事。导入失败不影响启动, 第一次同步会照常下载完整目录。
+func (s *Service) importEmbeddedSeed() {
+	if _, ok, err := s.GetSetting(seedSettingKey); err != nil || ok {
+		return
+	}
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM games;`).Scan(&count); err != nil || count > 0 {
+		return
+	}
+
+	file, err := seedFS.Open(embeddedSeedPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("目录快照：打开内置快照失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	info, err := s.ImportSeed(file)
+	if err != nil {
+		log.Printf("目录快照：导入失败, 将在同步时下载完整目录: %v", err)
+		return
+	}
+	log.Printf("目录快照：已导入 %d 个游戏, 隔离 %d 个 (快照生成于 %s)", info.Games, info.Quarantined, info.BuiltAt.Format("2006-01-02"))
+}
+
+// ImportSeed 把快照写入 games 表。所有行的 updated_at 都设为快照的游标,
+// 这样 GetLatestTimestamp 返回的正是快照的游标, 同步只需拉取之后的变化。
+// 已存在的游戏不会被覆盖。
+func (s *Service) ImportSeed(r io.Reader) (SeedInfo, error) {
+	seed, err := ReadSeed(r)
+	if err != nil {
+		return SeedInfo{}, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return SeedInfo{}, err
+	}
+	stmt, err := tx.Prepare(`
+        INSERT OR IGNORE INTO games (id, title_jp, title_cn, brand, release_date, release_precision, synopsis,
+            cover_url, preview_urls, tags, download_link, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		_ = tx.Rollback()
+		return SeedInfo{}, err
+	}
+	defer func(stmt *sql.Stmt) {
+		err := stmt.Close()
+		if err != nil {
+
+		}
+	}(stmt)
+
+	const layout = "2006-01-02 15:04:05"
+	cursor := seed.Cursor.UTC().Format(layout)
+	imported, quarantined := 0, 0
+	for _, game := range seed.Games {
+		cleaned, err := sanitize.Game(game)
+		if err != nil {
+			// 导入后游标直接跳到快照的游标, 对账也不会补拉缺少的ID, 因此不合格的行必须进入隔离区,
+			// 否则这些游戏会永久缺失; 修正清理规则后可以通过 RetryQuarantined 找回
+			log.Printf("目录快照：游戏ID %d 未通过校验, 已隔离: %v", game.ID, err)
+			if err := quarantineGame(tx, seedSyncID, models.QuarantineStageValidate, game, err); err != nil {
+				_ = tx.Rollback()
+				return SeedInfo{}, fmt.Errorf("隔离游戏ID %d 失败: %w", game.ID, err)
+			}
+			quarantined++
+			continue
+		}
+		game = cleaned
+		createdAt := cursor
+		if !game.CreatedAt.IsZero() {
+			createdAt = game.CreatedAt.UTC().Format(layout)
+		}
+		_, err = stmt.Exec(
+			game.ID, game.TitleJP, game.TitleCN, game.Brand, game.ReleaseDate.SortKey(), game.ReleaseDate.Precision,
+			game.Synopsis, game.CoverURL, game.PreviewURLs, game.Tags, game.DownloadLink, createdAt, cursor,
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return SeedInfo{}, fmt.Errorf("写入游戏ID %d 失败: %w", game.ID, err)
+		}
+		imported++
+	}
+
+	info := SeedInfo{BuiltAt: seed.BuiltAt, Cursor: seed.Cursor, Games: imported, Quarantined: quarantined, ImportedAt: time.Now().UTC()}
+	data, err := json.Marshal(info)
+	if err != nil {
+		_ = tx.Rollback()
+		return SeedInfo{}, err
+	}
+	if err := setSetting(tx, seedSettingKey, string(data)); err != nil {
+		_ = tx.Rollback()
+		return SeedInfo{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SeedInfo{}, err
+	}
+	return info, nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"galgame-gui/internal/models"
+)
+
+func TestImportSeedQuarantinesInvalidRows(t *testing.T) {
+	s := newTestService(t)
+	synopsis := "[a](javascript:alert(1))"
+	cursor := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	seed := Seed{
+		BuiltAt: cursor,
+		Cursor:  cursor,
+		Games: []models.Galgame{
+			{ID: 1, TitleJP: "ok"},
+			{ID: 2, TitleJP: "bad", Synopsis: &synopsis},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteSeed(&buf, seed); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := s.ImportSeed(&buf)
+	if err != nil {
+		t.Fatalf("ImportSeed: %v", err)
+	}
+	if info.Games != 1 || info.Quarantined != 1 {
+		t.Errorf("info = %+v, want 1 imported and 1 quarantined", info)
+	}
+
+	games, err := s.GetSyncedGames([]int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := games[1]; !ok {
+		t.Error("game 1 should have been imported")
+	}
+	if _, ok := games[2]; ok {
+		t.Error("game 2 should not have been imported")
+	}
+	latest, err := s.GetLatestTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !latest.Equal(cursor) {
+		t.Errorf("latest timestamp = %v, want %v", latest, cursor)
+	}
+
+	quarantined, err := s.ListQuarantinedGames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 {
+		t.Fatalf("quarantined = %d rows, want 1", len(quarantined))
+	}
+	q := quarantined[0]
+	if q.GameID != 2 || q.SyncID != seedSyncID || q.Stage != models.QuarantineStageValidate {
+		t.Errorf("quarantined row = %+v", q)
+	}
+
+	value, ok, err := s.GetSetting(seedSettingKey)
+	if err != nil || !ok {
+		t.Fatalf("seed setting missing: ok=%v err=%v", ok, err)
+	}
+	var saved SeedInfo
+	if err := json.Unmarshal([]byte(value), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Games != 1 || saved.Quarantined != 1 {
+		t.Errorf("saved info = %+v", saved)
+	}
+}
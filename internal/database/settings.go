@@ -0,0 +1,44 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// settings 保存少量应用级配置, 值由调用方自行编码 (通常为 JSON)。
+const createSettingsTableQuery = `
+    CREATE TABLE IF NOT EXISTS settings (
+        key TEXT PRIMARY KEY,
+        value TEXT NOT NULL,
+        updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+    );`
+
+// GetSetting 读取一项配置, 不存在时 ok 为 false。
+func (s *Service) GetSetting(key string) (value string, ok bool, err error) {
+	err = s.db.QueryRow(`SELECT value FROM settings WHERE key = ?;`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("读取配置 %s 失败: %w", key, err)
+	}
+	return value, true, nil
+}
+
+func (s *Service) SetSetting(key string, value string) error {
+	return setSetting(s.db, key, value)
+}
+
+// setSetting 写入一项配置, 可以在事务中调用。
+func setSetting(e execer, key string, value string) error {
+	_, err := e.Exec(`
+        INSERT INTO settings (key, value) VALUES (?, ?)
+        ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=strftime('%Y-%m-%d %H:%M:%S', 'now');`,
+		key, value,
+	)
+	if err != nil {
+		return fmt.Errorf("保存配置 %s 失败: %w", key, err)
+	}
+	return nil
+}
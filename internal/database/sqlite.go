@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"galgame-gui/internal/models"
+	"galgame-gui/internal/sanitize"
 	"log"
 	"strings"
 	"time"
@@ -13,24 +14,40 @@ import (
 )
 
 type Service struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
 }
 
 func NewService(dbPath string) (*Service, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL")
+	// 多个进程共用同一个数据库时, 写锁被占用会等待一段时间而不是立即返回 SQLITE_BUSY
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
 		return nil, fmt.Errorf("无法打开数据库: %w", err)
 	}
 
-	service := &Service{db: db}
-	if err = service.createTables(); err != nil {
+	service := &Service{db: db, path: dbPath}
+	if err = service.prepareSchema(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("无法创建表: %w", err)
+		return nil, err
 	}
+	service.importEmbeddedSeed()
 
 	return service, nil
 }
 
+func (s *Service) prepareSchema() error {
+	if err := s.createTables(); err != nil {
+		return fmt.Errorf("无法创建表: %w", err)
+	}
+	if err := s.migrate(); err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if err := s.createViews(); err != nil {
+		return fmt.Errorf("无法创建视图: %w", err)
+	}
+	return nil
+}
+
 func (s *Service) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	return s.db.Query(query, args...)
 }
@@ -77,11 +94,32 @@ func (s *Service) createTables() error {
 	return nil
 }
 
+// createViews 在每次启动 (以及恢复备份) 后重建视图, 视图不持久保存任何数据, 因此不走迁移。
+func (s *Service) createViews() error {
+	if _, err := s.db.Exec(`DROP VIEW IF EXISTS games_merged;`); err != nil {
+		return fmt.Errorf("删除旧的 games_merged 视图失败: %w", err)
+	}
+	if _, err := s.db.Exec(createMergedViewQuery); err != nil {
+		return fmt.Errorf("创建 games_merged 视图失败: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Service) GetLatestTimestamp() (time.Time, error) {
+	return latestTimestamp(s.db)
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// latestTimestamp 返回同步游标 MAX(updated_at), 可以在事务中调用。
+func latestTimestamp(q queryRower) (time.Time, error) {
 	var maxTimeString sql.NullString
 	query := `SELECT MAX(updated_at) FROM games;`
 
-	err := s.db.QueryRow(query).Scan(&maxTimeString)
+	err := q.QueryRow(query).Scan(&maxTimeString)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -124,10 +162,18 @@ func (s *Service) GetAllGameIDs() ([]int64, error) {
 	return ids, nil
 }
 
-func (s *Service) UpsertGames(games []models.Galgame) (int, error) {
+// UpsertGames 写入同步得到的游戏数据。未通过校验或写入失败的行会连同原因放入隔离区,
+// 并作为逐行错误返回, 不影响其余行的写入。
+func (s *Service) UpsertGames(games []models.Galgame, syncID string) (int, []models.RowError, error) {
+	return s.upsertGames(games, syncID, false)
+}
+
+// upsertGames 是 UpsertGames 的实现。keepCursor 为 true 时整行写入并沿用原来的时间戳,
+// 不会移动同步游标; 重试隔离数据时使用, 否则游标会跳到重试的时间, 跳过其间服务端的变化。
+func (s *Service) upsertGames(games []models.Galgame, syncID string, keepCursor bool) (int, []models.RowError, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -147,13 +193,14 @@ func (s *Service) UpsertGames(games []models.Galgame) (int, error) {
 	}()
 
 	stmt, err := tx.Prepare(`
-        INSERT INTO games (id, title_jp, title_cn, brand, release_date, synopsis, cover_url, preview_urls, tags, download_link)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO games (id, title_jp, title_cn, brand, release_date, release_precision, synopsis, cover_url, preview_urls, tags, download_link)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON CONFLICT(id) DO UPDATE SET
             title_jp=excluded.title_jp,
             title_cn=excluded.title_cn,
             brand=excluded.brand,
             release_date=excluded.release_date,
+            release_precision=excluded.release_precision,
             synopsis=excluded.synopsis,
             cover_url=excluded.cover_url,
             preview_urls=excluded.preview_urls,
@@ -161,7 +208,7 @@ func (s *Service) UpsertGames(games []models.Galgame) (int, error) {
             download_link=excluded.download_link;
     `)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 	defer func(stmt *sql.Stmt) {
 		err := stmt.Close()
@@ -171,20 +218,98 @@ func (s *Service) UpsertGames(games []models.Galgame) (int, error) {
 	}(stmt)
 
 	count := 0
+	changedAt := time.Now()
+	var cursor time.Time
+	if keepCursor {
+		if cursor, err = latestTimestamp(tx); err != nil {
+			return 0, nil, err
+		}
+	}
+	write := func(previous models.Galgame, created bool, game models.Galgame) error {
+		if !keepCursor {
+			_, err := stmt.Exec(
+				game.ID, game.TitleJP, game.TitleCN, game.Brand,
+				game.ReleaseDate.SortKey(), game.ReleaseDate.Precision, game.Synopsis, game.CoverURL,
+				game.PreviewURLs, game.Tags, game.DownloadLink,
+			)
+			return err
+		}
+		// 已有的游戏保留原来的时间, 新游戏使用当前的游标, 都不会超过游标
+		game.CreatedAt, game.UpdatedAt = changedAt, cursor
+		if !created {
+			game.CreatedAt, game.UpdatedAt = previous.CreatedAt, previous.UpdatedAt
+		}
+		return replaceSyncedGame(tx, game)
+	}
+	var rowErrors []models.RowError
+	var fatal error
+	reject := func(game models.Galgame, stage string, reason error) {
+		log.Printf("游戏ID %d 在%s阶段被拒绝, 已隔离: %v", game.ID, stage, reason)
+		rowErrors = append(rowErrors, models.RowError{GameID: game.ID, Stage: stage, Error: reason.Error()})
+		if err := quarantineGame(tx, syncID, stage, game, reason); err != nil {
+			log.Printf("隔离游戏ID %d 失败: %v", game.ID, err)
+		}
+	}
+
 	for _, game := range games {
-		_, err := stmt.Exec(
-			game.ID, game.TitleJP, game.TitleCN, game.Brand,
-			game.ReleaseDate, game.Synopsis, game.CoverURL,
-			game.PreviewURLs, game.Tags, game.DownloadLink,
-		)
+		game, err := sanitize.Game(game)
+		if err != nil {
+			reject(game, models.QuarantineStageValidate, err)
+			continue
+		}
+		previous, created, err := previousSyncedGame(tx, game.ID)
 		if err != nil {
-			log.Printf("插入/更新游戏ID %d 失败: %v", game.ID, err)
+			reject(game, models.QuarantineStageUpsert, err)
+			continue
+		}
+		var rowErr error
+		rowErr, fatal = upsertSyncedRow(tx, write, syncID, previous, created, game, changedAt)
+		if fatal != nil {
+			break
+		}
+		if rowErr != nil {
+			reject(game, models.QuarantineStageUpsert, rowErr)
 			continue
 		}
 		count++
 	}
+	if fatal != nil {
+		// 交给 defer 回滚整个事务
+		err = fatal
+		return 0, nil, err
+	}
 
-	return count, nil
+	return count, rowErrors, nil
+}
+
+// upsertSyncedRow 在保存点中写入一行并记录它的变更历史。任一步失败时撤销这一行并作为 rowErr 返回,
+// 与 UndoSyncRun 一样不允许没有历史记录的写入; 只有撤销本身失败时才返回 fatal, 由调用方回滚整个事务。
+// 写入成功后该游戏在隔离区中的旧记录都已过时, 一并删除, 以免之后重试时用旧数据覆盖新数据。
+func upsertSyncedRow(tx *sql.Tx, write func(previous models.Galgame, created bool, game models.Galgame) error, syncID string, previous models.Galgame, created bool, game models.Galgame, changedAt time.Time) (rowErr error, fatal error) {
+	if _, err := tx.Exec(`SAVEPOINT upsert_game;`); err != nil {
+		return nil, fmt.Errorf("创建保存点失败: %w", err)
+	}
+
+	rowErr = write(previous, created, game)
+	if rowErr == nil {
+		if err := recordGameHistory(tx, syncID, previous, created, game, changedAt); err != nil {
+			rowErr = fmt.Errorf("记录变更历史失败: %w", err)
+		}
+	}
+	if rowErr == nil {
+		if _, err := tx.Exec(`DELETE FROM quarantined_games WHERE game_id = ?;`, game.ID); err != nil {
+			rowErr = fmt.Errorf("移除过时的隔离数据失败: %w", err)
+		}
+	}
+	if rowErr != nil {
+		if _, err := tx.Exec(`ROLLBACK TO upsert_game;`); err != nil {
+			return nil, fmt.Errorf("撤销游戏ID %d 的写入失败: %w", game.ID, err)
+		}
+	}
+	if _, err := tx.Exec(`RELEASE upsert_game;`); err != nil {
+		return nil, fmt.Errorf("释放保存点失败: %w", err)
+	}
+	return rowErr, nil
 }
 
 func (s *Service) DeleteGames(ids []int64) (int, error) {
@@ -214,19 +339,41 @@ func (s *Service) DeleteGames(ids []int64) (int, error) {
 	return int(rowsAffected), nil
 }
 
-func (s *Service) GetGameByID(id int64) (models.Galgame, error) {
-	var game models.Galgame
-	query := `SELECT 
-                id, title_jp, title_cn, brand, release_date, 
+const selectMergedGameColumns = `SELECT 
+                id, title_jp, title_cn, brand, release_date, release_precision,
                 synopsis, cover_url, preview_urls, tags, download_link, 
-                created_at, updated_at
-              FROM games WHERE id = ?;`
+                created_at, updated_at, overridden_fields, is_local,
+                cover_blurhash, cover_dominant_color, cover_accent_color
+              FROM games_merged`
 
-	err := s.db.QueryRow(query, id).Scan(
-		&game.ID, &game.TitleJP, &game.TitleCN, &game.Brand, &game.ReleaseDate,
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMergedGame(row rowScanner) (models.Galgame, error) {
+	var game models.Galgame
+	var releaseDate sql.NullTime
+	var releasePrecision string
+	var overriddenFields, blurhash, dominantColor, accentColor sql.NullString
+	err := row.Scan(
+		&game.ID, &game.TitleJP, &game.TitleCN, &game.Brand, &releaseDate, &releasePrecision,
 		&game.Synopsis, &game.CoverURL, &game.PreviewURLs, &game.Tags, &game.DownloadLink,
-		&game.CreatedAt, &game.UpdatedAt,
+		&game.CreatedAt, &game.UpdatedAt, &overriddenFields, &game.IsLocal,
+		&blurhash, &dominantColor, &accentColor,
 	)
+	if err != nil {
+		return models.Galgame{}, err
+	}
+	game.ReleaseDate = models.ReleaseDateFromSortKey(releaseDate.Time, releasePrecision)
+	game.OverriddenFields = SplitOverriddenFields(overriddenFields.String)
+	game.CoverBlurhash = blurhash.String
+	game.CoverDominantColor = dominantColor.String
+	game.CoverAccentColor = accentColor.String
+	return game, nil
+}
+
+func (s *Service) GetGameByID(id int64) (models.Galgame, error) {
+	game, err := scanMergedGame(s.db.QueryRow(selectMergedGameColumns+` WHERE id = ?;`, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Galgame{}, fmt.Errorf("未找到ID为 %d 的游戏", id)
@@ -236,6 +383,47 @@ func (s *Service) GetGameByID(id int64) (models.Galgame, error) {
 	return game, nil
 }
 
+// ListGames 按给定的 WHERE 子句 (可为空) 返回合并了覆盖值和本地游戏的完整游戏数据。
+func (s *Service) ListGames(whereClause string, args ...interface{}) ([]models.Galgame, error) {
+	query := fmt.Sprintf(`%s %s ORDER BY release_date DESC, id DESC;`, selectMergedGameColumns, whereClause)
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询游戏列表失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	var games []models.Galgame
+	for rows.Next() {
+		game, err := scanMergedGame(rows)
+		if err != nil {
+			log.Printf("扫描游戏行失败: %v", err)
+			continue
+		}
+		games = append(games, game)
+	}
+	return games, rows.Err()
+}
+
+func (s *Service) GetGamesByIDs(ids []int64) ([]models.Galgame, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(ids))
+	placeholders = placeholders[:len(placeholders)-1]
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return s.ListGames(fmt.Sprintf("WHERE id IN (%s)", placeholders), args...)
+}
+
 func (s *Service) UpdateDownloadLink(id int64, link string) error {
 	query := `UPDATE games SET download_link = ? WHERE id = ?;`
 	nullLink := sql.NullString{String: link, Valid: link != ""}
@@ -262,3 +450,23 @@ func (s *Service) Close() {
 		}
 	}
 }
+
+// ListReleasesBetween 返回发售时间段与 [from, to] 有交集的游戏。release_date 存的是时间段的最后一天,
+// 因此先用它筛掉已经结束的, 再按时间段的第一天筛掉太晚的。includeUnknown 为 true 时附带日期未定的游戏。
+func (s *Service) ListReleasesBetween(from time.Time, to time.Time, includeUnknown bool) ([]models.Galgame, error) {
+	games, err := s.ListGames(
+		`WHERE (release_precision NOT IN (?, ?) AND release_date >= ?) OR (? AND release_precision = ?)`,
+		models.PrecisionNone, models.PrecisionUnknown, from.UTC(), includeUnknown, models.PrecisionUnknown,
+	)
+	if err != nil {
+		return nil, err
+	}
+	result := games[:0]
+	for _, game := range games {
+		if game.ReleaseDate.Precision != models.PrecisionUnknown && game.ReleaseDate.Time.After(to) {
+			continue
+		}
+		result = append(result, game)
+	}
+	return result, nil
+}
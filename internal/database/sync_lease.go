@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sync_lease 是跨进程的同步锁, 每个名字一行。持有者需要在 expires_at 之前续期,
+// 进程崩溃后租约过期, 其他进程即可接手。
+const createSyncLeaseTableQuery = `
+    CREATE TABLE IF NOT EXISTS sync_lease (
+        name TEXT PRIMARY KEY,
+        owner TEXT NOT NULL,
+        acquired_at DATETIME NOT NULL,
+        expires_at DATETIME NOT NULL
+    );`
+
+// SyncLease 是租约的当前持有者。
+type SyncLease struct {
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AcquireLease 在租约空闲、已过期或本来就由 owner 持有时获得 (或续期) 租约, 否则返回 false。
+// 判断和写入在同一条语句中完成, 多个进程同时争抢时只有一个会成功。
+func (s *Service) AcquireLease(name string, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`
+        INSERT INTO sync_lease (name, owner, acquired_at, expires_at) VALUES (?, ?, ?, ?)
+        ON CONFLICT(name) DO UPDATE SET
+            owner = excluded.owner,
+            acquired_at = CASE WHEN sync_lease.owner = excluded.owner THEN sync_lease.acquired_at ELSE excluded.acquired_at END,
+            expires_at = excluded.expires_at
+        WHERE sync_lease.owner = excluded.owner OR sync_lease.expires_at < ?;`,
+		name, owner, now, now.Add(ttl), now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("获取同步锁失败: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease 释放 owner 持有的租约, 租约已被其他进程接手时不做任何事。
+func (s *Service) ReleaseLease(name string, owner string) error {
+	if _, err := s.db.Exec(`DELETE FROM sync_lease WHERE name = ? AND owner = ?;`, name, owner); err != nil {
+		return fmt.Errorf("释放同步锁失败: %w", err)
+	}
+	return nil
+}
+
+// GetLease 返回未过期的租约, 没有人持有时返回 nil。
+func (s *Service) GetLease(name string) (*SyncLease, error) {
+	lease := SyncLease{Name: name}
+	err := s.db.QueryRow(`SELECT owner, expires_at FROM sync_lease WHERE name = ? AND expires_at >= ?;`,
+		name, time.Now().UTC(),
+	).Scan(&lease.Owner, &lease.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取同步锁失败: %w", err)
+	}
+	return &lease, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquireLease(t *testing.T) {
+	s := newTestService(t)
+	acquire := func(owner string, ttl time.Duration, want bool) {
+		t.Helper()
+		got, err := s.AcquireLease("sync", owner, ttl)
+		if err != nil {
+			t.Fatalf("AcquireLease(%s): %v", owner, err)
+		}
+		if got != want {
+			t.Fatalf("AcquireLease(%s) = %v, want %v", owner, got, want)
+		}
+	}
+
+	acquire("a", time.Minute, true)
+	acquire("b", time.Minute, false)
+	// 持有者续期
+	acquire("a", time.Minute, true)
+
+	lease, err := s.GetLease("sync")
+	if err != nil || lease == nil || lease.Owner != "a" {
+		t.Fatalf("GetLease = %+v, %v; want owner a", lease, err)
+	}
+
+	// 其他进程不能释放不属于自己的租约
+	if err := s.ReleaseLease("sync", "b"); err != nil {
+		t.Fatal(err)
+	}
+	acquire("b", time.Minute, false)
+
+	if err := s.ReleaseLease("sync", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if lease, err := s.GetLease("sync"); err != nil || lease != nil {
+		t.Fatalf("GetLease after release = %+v, %v; want nil", lease, err)
+	}
+	acquire("b", -time.Second, true)
+
+	// 过期的租约可以被接手, 过期之后 GetLease 视为无人持有
+	if lease, err := s.GetLease("sync"); err != nil || lease != nil {
+		t.Fatalf("GetLease on expired lease = %+v, %v; want nil", lease, err)
+	}
+	acquire("a", time.Minute, true)
+	acquire("b", time.Minute, false)
+
+	// 不同名字的租约互不影响
+	got, err := s.AcquireLease("other", "b", time.Minute)
+	if err != nil || !got {
+		t.Fatalf("AcquireLease(other) = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+	"strings"
+	"time"
+)
+
+const createSyncRunsTableQuery = `
+    CREATE TABLE IF NOT EXISTS sync_runs (
+        id TEXT PRIMARY KEY,
+        started_at DATETIME NOT NULL,
+        finished_at DATETIME,
+        added INTEGER NOT NULL DEFAULT 0,
+        updated INTEGER NOT NULL DEFAULT 0,
+        removed INTEGER NOT NULL DEFAULT 0
+    );`
+
+// sync_changes 每个变更字段一行; 新增和删除的游戏只有一行, field 为空。
+const createSyncChangesTableQuery = `
+    CREATE TABLE IF NOT EXISTS sync_changes (
+        run_id TEXT NOT NULL,
+        game_id INTEGER NOT NULL,
+        change_type TEXT NOT NULL,
+        title TEXT NOT NULL DEFAULT '',
+        field TEXT NOT NULL DEFAULT '',
+        old_value TEXT,
+        new_value TEXT
+    );
+    CREATE INDEX IF NOT EXISTS idx_sync_changes_run ON sync_changes(run_id);`
+
+const selectSyncedGameColumns = `SELECT
+                id, title_jp, title_cn, brand, release_date, release_precision,
+                synopsis, cover_url, preview_urls, tags, download_link, created_at, updated_at
+              FROM games`
+
+// GetSyncedGames 直接从 games 表读取同步下来的原始数据 (不含覆盖值), 用于计算同步前后的差异。
+func (s *Service) GetSyncedGames(ids []int64) (map[int64]models.Galgame, error) {
+	result := make(map[int64]models.Galgame, len(ids))
+	// SQLite 对参数个数有上限, 分批查询
+	const batchSize = 500
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		rows, err := s.db.Query(fmt.Sprintf(`%s WHERE id IN (%s);`, selectSyncedGameColumns, placeholders), args...)
+		if err != nil {
+			return nil, fmt.Errorf("查询同步数据失败: %w", err)
+		}
+		for rows.Next() {
+			game, err := scanSyncedGame(rows)
+			if err != nil {
+				log.Printf("扫描同步数据失败: %v", err)
+				continue
+			}
+			result[game.ID] = game
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func scanSyncedGame(row rowScanner) (models.Galgame, error) {
+	var game models.Galgame
+	var releaseDate sql.NullTime
+	var releasePrecision string
+	err := row.Scan(
+		&game.ID, &game.TitleJP, &game.TitleCN, &game.Brand, &releaseDate, &releasePrecision,
+		&game.Synopsis, &game.CoverURL, &game.PreviewURLs, &game.Tags, &game.DownloadLink,
+		&game.CreatedAt, &game.UpdatedAt,
+	)
+	if err != nil {
+		return models.Galgame{}, err
+	}
+	game.ReleaseDate = models.ReleaseDateFromSortKey(releaseDate.Time, releasePrecision)
+	return game, nil
+}
+
+// StartSyncRun 在同步开始时写入一条进行中的记录, 应用在同步途中退出时也能留下痕迹。
+func (s *Service) StartSyncRun(run models.SyncRun) error {
+	_, err := s.db.Exec(`
+        INSERT INTO sync_runs (id, started_at, status, has_snapshot)
+        VALUES (?, ?, ?, 1);`,
+		run.ID, run.StartedAt.UTC(), models.SyncStatusRunning,
+	)
+	if err != nil {
+		return fmt.Errorf("保存同步记录失败: %w", err)
+	}
+	return nil
+}
+
+// FinishSyncRun 更新同步的结果并保存变更明细。失败的同步也会保存已经发生的变更。
+func (s *Service) FinishSyncRun(run models.SyncRun, changes []models.SyncChange) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        UPDATE sync_runs SET finished_at = ?, status = ?, error = ?,
+            added = ?, updated = ?, removed = ?, deleted = ?, fetched = ?, upserted = ?, rejected = ?
+        WHERE id = ?;`,
+		run.FinishedAt.UTC(), run.Status, run.Error,
+		run.Added, run.Updated, run.Removed, run.Deleted, run.Fetched, run.Upserted, run.Rejected,
+		run.ID,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("保存同步记录失败: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO sync_changes (run_id, game_id, change_type, title, field, old_value, new_value)
+        VALUES (?, ?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func(stmt *sql.Stmt) {
+		err := stmt.Close()
+		if err != nil {
+
+		}
+	}(stmt)
+
+	for _, change := range changes {
+		if len(change.Fields) == 0 {
+			_, err = stmt.Exec(run.ID, change.GameID, change.Type, change.Title, "", nil, nil)
+		}
+		for _, field := range change.Fields {
+			if _, err = stmt.Exec(run.ID, change.GameID, change.Type, change.Title, field.Field, field.Old, field.New); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("保存同步变更失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// AbandonSyncRuns 把仍标记为进行中的同步改为失败, 用于启动时清理上次退出前未完成的同步。
+func (s *Service) AbandonSyncRuns(reason string) (int, error) {
+	result, err := s.db.Exec(`
+        UPDATE sync_runs SET status = ?, error = ?, finished_at = ?
+        WHERE status = ?;`,
+		models.SyncStatusFailed, reason, time.Now().UTC(), models.SyncStatusRunning,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("更新未完成的同步记录失败: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+const selectSyncRunColumns = `SELECT
+                id, started_at, finished_at, status, COALESCE(error, ''),
+                added, updated, removed, deleted, fetched, upserted, rejected, undone_at
+              FROM sync_runs `
+
+func scanSyncRun(row rowScanner) (models.SyncRun, error) {
+	var run models.SyncRun
+	var finishedAt, undoneAt sql.NullTime
+	err := row.Scan(
+		&run.ID, &run.StartedAt, &finishedAt, &run.Status, &run.Error,
+		&run.Added, &run.Updated, &run.Removed, &run.Deleted, &run.Fetched, &run.Upserted, &run.Rejected, &undoneAt,
+	)
+	if err != nil {
+		return models.SyncRun{}, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = finishedAt.Time
+		run.DurationMS = finishedAt.Time.Sub(run.StartedAt).Milliseconds()
+	}
+	if undoneAt.Valid {
+		run.UndoneAt = &undoneAt.Time
+	}
+	return run, nil
+}
+
+func (s *Service) querySyncRuns(query string, args ...interface{}) ([]models.SyncRun, error) {
+	rows, err := s.db.Query(selectSyncRunColumns+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询同步记录失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	runs := []models.SyncRun{}
+	for rows.Next() {
+		run, err := scanSyncRun(rows)
+		if err != nil {
+			log.Printf("扫描同步记录失败: %v", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// ListSyncRunsAfter 按时间从旧到新返回 sinceRun 之后已结束的同步记录; sinceRun 为空时只返回最近一次。
+func (s *Service) ListSyncRunsAfter(sinceRun string) ([]models.SyncRun, error) {
+	if sinceRun == "" {
+		return s.querySyncRuns(`WHERE status != ? ORDER BY started_at DESC LIMIT 1;`, models.SyncStatusRunning)
+	}
+	return s.querySyncRuns(`
+        WHERE status != ? AND started_at > (SELECT started_at FROM sync_runs WHERE id = ?)
+        ORDER BY started_at;`, models.SyncStatusRunning, sinceRun)
+}
+
+// ListSyncRuns 返回最近 limit 次同步, 最新的在前, 包括进行中和失败的同步。
+func (s *Service) ListSyncRuns(limit int) ([]models.SyncRun, error) {
+	return s.querySyncRuns(`ORDER BY started_at DESC LIMIT ?;`, limit)
+}
+
+// LatestSyncRun 返回指定状态的最近一次同步, 没有时返回 nil。
+func (s *Service) LatestSyncRun(status string) (*models.SyncRun, error) {
+	run, err := scanSyncRun(s.db.QueryRow(selectSyncRunColumns+`WHERE status = ? ORDER BY started_at DESC LIMIT 1;`, status))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询同步记录失败: %w", err)
+	}
+	return &run, nil
+}
+
+// ListSyncChanges 返回指定同步中的变更, 同一游戏的字段变更合并为一项。
+func (s *Service) ListSyncChanges(runIDs []string) ([]models.SyncChange, error) {
+	if len(runIDs) == 0 {
+		return []models.SyncChange{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(runIDs)), ",")
+	args := make([]interface{}, len(runIDs))
+	for i, id := range runIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+        SELECT c.run_id, c.game_id, c.change_type, c.title, c.field, COALESCE(c.old_value, ''), COALESCE(c.new_value, '')
+        FROM sync_changes c JOIN sync_runs r ON r.id = c.run_id
+        WHERE c.run_id IN (%s)
+        ORDER BY r.started_at, c.rowid;`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询同步变更失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	changes := []models.SyncChange{}
+	for rows.Next() {
+		var change models.SyncChange
+		var field models.FieldChange
+		if err := rows.Scan(&change.RunID, &change.GameID, &change.Type, &change.Title, &field.Field, &field.Old, &field.New); err != nil {
+			log.Printf("扫描同步变更失败: %v", err)
+			continue
+		}
+		if n := len(changes); n > 0 && changes[n-1].RunID == change.RunID && changes[n-1].GameID == change.GameID {
+			changes[n-1].Fields = append(changes[n-1].Fields, field)
+			continue
+		}
+		if field.Field != "" {
+			change.Fields = []models.FieldChange{field}
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/models"
+	"time"
+)
+
+// sync_snapshots 保存每次同步改动之前的行, existed 为 0 表示该游戏是这次同步新增的。
+// 撤销同步时按快照把 games 表恢复原状。
+const createSyncSnapshotsTableQuery = `
+    CREATE TABLE IF NOT EXISTS sync_snapshots (
+        run_id TEXT NOT NULL,
+        game_id INTEGER NOT NULL,
+        existed INTEGER NOT NULL,
+        raw TEXT,
+        PRIMARY KEY (run_id, game_id)
+    );`
+
+// SaveSyncSnapshot 在同步改动 ids 之前保存它们的当前版本, previous 中没有的视为新增。
+// 同一次同步中先保存的快照优先。
+func (s *Service) SaveSyncSnapshot(runID string, ids []int64, previous map[int64]models.Galgame) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		var raw interface{}
+		game, existed := previous[id]
+		if existed {
+			data, err := json.Marshal(game)
+			if err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("编码游戏ID %d 的快照失败: %w", id, err)
+			}
+			raw = string(data)
+		}
+		_, err := tx.Exec(`INSERT OR IGNORE INTO sync_snapshots (run_id, game_id, existed, raw) VALUES (?, ?, ?, ?);`,
+			runID, id, existed, raw)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("保存同步快照失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneSyncSnapshots 只保留最近 keep 次同步的快照, 更早的同步不能再撤销。
+// 快照保存了被改动行的完整内容, 第一次同步时就是整个目录, 不清理的话会无限增长。
+func (s *Service) PruneSyncSnapshots(keep int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	const recent = `SELECT id FROM sync_runs ORDER BY started_at DESC LIMIT ?`
+	if _, err := tx.Exec(`UPDATE sync_runs SET has_snapshot = 0 WHERE has_snapshot = 1 AND id NOT IN (`+recent+`);`, keep); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("更新同步记录失败: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM sync_snapshots WHERE run_id NOT IN (`+recent+`);`, keep); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("清理同步快照失败: %w", err)
+	}
+	return tx.Commit()
+}
+
+// LastUndoableSyncRun 返回最近一次尚未撤销、且确实改动过数据的同步。没有写入任何数据的同步
+// (例如网络错误导致的失败) 被跳过, 撤销它们只会把同步游标固定在失败的时间, 让之后的更新被悄悄跳过。
+func (s *Service) LastUndoableSyncRun() (models.SyncRun, error) {
+	var run models.SyncRun
+	var finishedAt sql.NullTime
+	var hasSnapshot bool
+	err := s.db.QueryRow(`
+        SELECT id, started_at, finished_at, added, updated, removed, has_snapshot
+        FROM sync_runs r
+        WHERE undone_at IS NULL AND status != ?
+          AND (EXISTS (SELECT 1 FROM sync_snapshots WHERE run_id = r.id)
+               OR EXISTS (SELECT 1 FROM sync_changes WHERE run_id = r.id))
+        ORDER BY started_at DESC LIMIT 1;`, models.SyncStatusRunning,
+	).Scan(&run.ID, &run.StartedAt, &finishedAt, &run.Added, &run.Updated, &run.Removed, &hasSnapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SyncRun{}, fmt.Errorf("没有可以撤销的同步")
+	}
+	if err != nil {
+		return models.SyncRun{}, fmt.Errorf("查询同步记录失败: %w", err)
+	}
+	if !hasSnapshot {
+		return models.SyncRun{}, fmt.Errorf("同步 %s 没有保存快照, 无法撤销", run.ID)
+	}
+	run.FinishedAt = finishedAt.Time
+	return run, nil
+}
+
+// UndoSyncRun 按快照恢复一次同步改动过的行: 新增的游戏被删除, 更新和删除的游戏恢复为同步前的版本
+// (包括 updated_at, 因此不会影响同步游标以外的排序)。返回被恢复的、原本被这次同步删除的游戏ID。
+func (s *Service) UndoSyncRun(runID string) ([]int64, error) {
+	rows, err := s.db.Query(`SELECT game_id, existed, raw FROM sync_snapshots WHERE run_id = ?;`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("读取同步快照失败: %w", err)
+	}
+	type snapshot struct {
+		gameID  int64
+		existed bool
+		raw     sql.NullString
+	}
+	var snapshots []snapshot
+	for rows.Next() {
+		var snap snapshot
+		if err := rows.Scan(&snap.gameID, &snap.existed, &snap.raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("读取同步快照失败: %w", err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	undoSyncID := "undo:" + runID
+	changedAt := time.Now()
+	var restoredDeleted []int64
+	for _, snap := range snapshots {
+		current, missing, err := previousSyncedGame(tx, snap.gameID)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if !snap.existed {
+			if _, err := tx.Exec(`DELETE FROM games WHERE id = ?;`, snap.gameID); err != nil {
+				_ = tx.Rollback()
+				return nil, fmt.Errorf("删除同步新增的游戏ID %d 失败: %w", snap.gameID, err)
+			}
+			continue
+		}
+
+		var game models.Galgame
+		if err := json.Unmarshal([]byte(snap.raw.String), &game); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("解析游戏ID %d 的快照失败: %w", snap.gameID, err)
+		}
+		if err := replaceSyncedGame(tx, game); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("恢复游戏ID %d 失败: %w", snap.gameID, err)
+		}
+		if missing {
+			restoredDeleted = append(restoredDeleted, snap.gameID)
+			continue
+		}
+		if err := recordGameHistory(tx, undoSyncID, current, false, game, changedAt); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("记录游戏ID %d 的变更历史失败: %w", snap.gameID, err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE sync_runs SET undone_at = ? WHERE id = ?;`, changedAt.UTC(), runID); err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("标记同步 %s 为已撤销失败: %w", runID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return restoredDeleted, nil
+}
+
+// replaceSyncedGame 整行写入 games, 保留原来的 created_at 和 updated_at。
+// INSERT OR REPLACE 是先删后插, 不会触发 updated_at 触发器, 因此也不会移动同步游标。
+func replaceSyncedGame(tx *sql.Tx, game models.Galgame) error {
+	const layout = "2006-01-02 15:04:05"
+	_, err := tx.Exec(`
+        INSERT OR REPLACE INTO games (id, title_jp, title_cn, brand, release_date, release_precision, synopsis,
+            cover_url, preview_urls, tags, download_link, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		game.ID, game.TitleJP, game.TitleCN, game.Brand, game.ReleaseDate.SortKey(), game.ReleaseDate.Precision,
+		game.Synopsis, game.CoverURL, game.PreviewURLs, game.Tags, game.DownloadLink,
+		game.CreatedAt.UTC().Format(layout), game.UpdatedAt.UTC().Format(layout),
+	)
+	return err
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"galgame-gui/internal/models"
+)
+
+func TestUpsertGamesRejectsRowWhenHistoryFails(t *testing.T) {
+	s := newTestService(t)
+	if _, _, err := s.UpsertGames([]models.Galgame{{ID: 1, TitleJP: "一"}, {ID: 2, TitleJP: "二"}}, "first"); err != nil {
+		t.Fatal(err)
+	}
+	// 只让游戏2的历史记录写入失败
+	if _, err := s.db.Exec(`
+        CREATE TRIGGER fail_history BEFORE INSERT ON game_history
+        WHEN NEW.game_id = 2
+        BEGIN SELECT RAISE(ABORT, 'history unavailable'); END;`); err != nil {
+		t.Fatal(err)
+	}
+
+	count, rowErrors, err := s.UpsertGames([]models.Galgame{{ID: 1, TitleJP: "一改"}, {ID: 2, TitleJP: "二改"}}, "second")
+	if err != nil {
+		t.Fatalf("UpsertGames: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].GameID != 2 || rowErrors[0].Stage != models.QuarantineStageUpsert {
+		t.Errorf("rowErrors = %+v", rowErrors)
+	}
+
+	games, err := s.GetSyncedGames([]int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := games[1].TitleJP; got != "一改" {
+		t.Errorf("game 1 title = %q, want the new title", got)
+	}
+	if got := games[2].TitleJP; got != "二" {
+		t.Errorf("game 2 title = %q, want the old title to be kept", got)
+	}
+
+	quarantined, err := s.ListQuarantinedGames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 || quarantined[0].GameID != 2 || quarantined[0].SyncID != "second" {
+		t.Errorf("quarantined = %+v", quarantined)
+	}
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/models"
+	"log"
+)
+
+const createUserGamesTableQuery = `
+    CREATE TABLE IF NOT EXISTS user_games (
+        game_id INTEGER PRIMARY KEY,
+        status TEXT NOT NULL DEFAULT '',
+        rating INTEGER NOT NULL DEFAULT 0 CHECK (rating BETWEEN 0 AND 10),
+        notes TEXT NOT NULL DEFAULT '',
+        updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now'))
+    );`
+
+func validateUserGame(ug models.UserGame) error {
+	if !models.IsValidUserStatus(ug.Status) {
+		return fmt.Errorf("无效的游玩状态: %s", ug.Status)
+	}
+	if ug.Rating < 0 || ug.Rating > 10 {
+		return fmt.Errorf("评分必须在 0 到 10 之间: %d", ug.Rating)
+	}
+	return nil
+}
+
+func (s *Service) SetUserGame(ug models.UserGame) error {
+	if err := validateUserGame(ug); err != nil {
+		return err
+	}
+	query := `
+        INSERT INTO user_games (game_id, status, rating, notes)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT(game_id) DO UPDATE SET
+            status=excluded.status,
+            rating=excluded.rating,
+            notes=excluded.notes,
+            updated_at=strftime('%Y-%m-%d %H:%M:%S', 'now');`
+	if _, err := s.db.Exec(query, ug.GameID, ug.Status, ug.Rating, ug.Notes); err != nil {
+		return fmt.Errorf("保存用户游戏数据失败: %w", err)
+	}
+	return nil
+}
+
+// MergeUserGames 批量写入用户数据, 评分为 0 或备注为空时保留已有的值, 供导入使用。
+func (s *Service) MergeUserGames(items []models.UserGame) (int, error) {
+	for _, ug := range items {
+		if err := validateUserGame(ug); err != nil {
+			return 0, fmt.Errorf("游戏ID %d: %w", ug.GameID, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO user_games (game_id, status, rating, notes)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT(game_id) DO UPDATE SET
+            status=CASE WHEN excluded.status <> '' THEN excluded.status ELSE user_games.status END,
+            rating=CASE WHEN excluded.rating > 0 THEN excluded.rating ELSE user_games.rating END,
+            notes=CASE WHEN excluded.notes <> '' THEN excluded.notes ELSE user_games.notes END,
+            updated_at=strftime('%Y-%m-%d %H:%M:%S', 'now');`)
+	if err != nil {
+		return 0, err
+	}
+	defer func(stmt *sql.Stmt) {
+		err := stmt.Close()
+		if err != nil {
+
+		}
+	}(stmt)
+
+	count := 0
+	for _, ug := range items {
+		if _, err = stmt.Exec(ug.GameID, ug.Status, ug.Rating, ug.Notes); err != nil {
+			return 0, fmt.Errorf("写入游戏ID %d 的用户数据失败: %w", ug.GameID, err)
+		}
+		count++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Service) GetUserGame(gameID int64) (models.UserGame, error) {
+	ug := models.UserGame{GameID: gameID}
+	err := s.db.QueryRow(`SELECT status, rating, notes, updated_at FROM user_games WHERE game_id = ?;`, gameID).
+		Scan(&ug.Status, &ug.Rating, &ug.Notes, &ug.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.UserGame{}, fmt.Errorf("查询用户游戏数据失败: %w", err)
+	}
+	return ug, nil
+}
+
+func (s *Service) ListUserGames() (map[int64]models.UserGame, error) {
+	rows, err := s.db.Query(`SELECT game_id, status, rating, notes, updated_at FROM user_games;`)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户游戏数据失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	result := make(map[int64]models.UserGame)
+	for rows.Next() {
+		var ug models.UserGame
+		if err := rows.Scan(&ug.GameID, &ug.Status, &ug.Rating, &ug.Notes, &ug.UpdatedAt); err != nil {
+			log.Printf("扫描用户游戏数据失败: %v", err)
+			continue
+		}
+		result[ug.GameID] = ug
+	}
+	return result, rows.Err()
+}
+
+// ListUserGameIDs 返回有用户数据的游戏ID, status 为空时不按状态过滤。
+func (s *Service) ListUserGameIDs(status string) ([]int64, error) {
+	query := `SELECT game_id FROM user_games WHERE (? = '' OR status = ?);`
+	rows, err := s.db.Query(query, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户游戏列表失败: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("扫描游戏ID失败: %v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
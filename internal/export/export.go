@@ -0,0 +1,246 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"galgame-gui/internal/models"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "md"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("不支持的导出格式: %s", s)
+}
+
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func (f Format) DisplayName() string {
+	switch f {
+	case FormatJSON:
+		return "JSON 文件 (*.json)"
+	case FormatCSV:
+		return "CSV 表格 (*.csv)"
+	case FormatMarkdown:
+		return "Markdown 文档 (*.md)"
+	}
+	return string(f)
+}
+
+type Record struct {
+	ID           int64  `json:"id"`
+	TitleJP      string `json:"title_jp"`
+	TitleCN      string `json:"title_cn"`
+	Brand        string `json:"brand"`
+	ReleaseDate  string `json:"release_date"`
+	Tags         string `json:"tags"`
+	Synopsis     string `json:"synopsis"`
+	CoverURL     string `json:"cover_url"`
+	PreviewURLs  string `json:"preview_urls"`
+	DownloadLink string `json:"download_link"`
+	IsLocal      bool   `json:"is_local"`
+	Status       string `json:"status"`
+	Rating       int    `json:"rating"`
+	Notes        string `json:"notes"`
+}
+
+func stringFromPtr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func FromGame(game models.Galgame, userGame models.UserGame) Record {
+	return Record{
+		ID:           game.ID,
+		TitleJP:      game.TitleJP,
+		TitleCN:      stringFromPtr(game.TitleCN),
+		Brand:        stringFromPtr(game.Brand),
+		ReleaseDate:  game.ReleaseDate.ISO(),
+		Tags:         stringFromPtr(game.Tags),
+		Synopsis:     stringFromPtr(game.Synopsis),
+		CoverURL:     stringFromPtr(game.CoverURL),
+		PreviewURLs:  stringFromPtr(game.PreviewURLs),
+		DownloadLink: stringFromPtr(game.DownloadLink),
+		IsLocal:      game.IsLocal,
+		Status:       userGame.Status,
+		Rating:       userGame.Rating,
+		Notes:        userGame.Notes,
+	}
+}
+
+func FromGames(games []models.Galgame, userGames map[int64]models.UserGame) []Record {
+	records := make([]Record, len(games))
+	for i, game := range games {
+		records[i] = FromGame(game, userGames[game.ID])
+	}
+	return records
+}
+
+func Write(w io.Writer, format Format, records []Record) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, records)
+	case FormatCSV:
+		return writeCSV(w, records)
+	case FormatMarkdown:
+		return writeMarkdown(w, records)
+	}
+	return fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+func WriteFile(path string, format Format, records []Record) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+
+	buffered := bufio.NewWriter(file)
+	if err := Write(buffered, format, records); err != nil {
+		file.Close()
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	return file.Close()
+}
+
+func writeJSON(w io.Writer, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(records)
+}
+
+var csvHeader = []string{
+	"id", "title_jp", "title_cn", "brand", "release_date", "tags",
+	"synopsis", "cover_url", "preview_urls", "download_link", "is_local",
+	"status", "rating", "notes",
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	// 写入 UTF-8 BOM, 否则 Excel 打开中日文内容会乱码
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			strconv.FormatInt(r.ID, 10), r.TitleJP, r.TitleCN, r.Brand, r.ReleaseDate, r.Tags,
+			r.Synopsis, r.CoverURL, r.PreviewURLs, r.DownloadLink, strconv.FormatBool(r.IsLocal),
+			r.Status, strconv.Itoa(r.Rating), r.Notes,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeMarkdown(w io.Writer, records []Record) error {
+	var b strings.Builder
+	b.WriteString("# ShiroGal 游戏列表\n\n")
+	fmt.Fprintf(&b, "共 %d 个游戏。\n", len(records))
+
+	for _, r := range records {
+		title := r.TitleCN
+		if title == "" {
+			title = r.TitleJP
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", markdownInline(title))
+		if r.TitleCN != "" {
+			fmt.Fprintf(&b, "- 原名: %s\n", markdownInline(r.TitleJP))
+		}
+		fmt.Fprintf(&b, "- ID: %d\n", r.ID)
+		writeMarkdownItem(&b, "品牌", r.Brand)
+		writeMarkdownItem(&b, "发售日期", r.ReleaseDate)
+		writeMarkdownItem(&b, "标签", r.Tags)
+		if r.IsLocal {
+			b.WriteString("- 来源: 本地添加\n")
+		}
+		writeMarkdownItem(&b, "游玩状态", statusLabels[r.Status])
+		if r.Rating > 0 {
+			fmt.Fprintf(&b, "- 评分: %d/10\n", r.Rating)
+		}
+		writeMarkdownItem(&b, "备注", r.Notes)
+		if r.CoverURL != "" {
+			fmt.Fprintf(&b, "\n![封面](%s)\n", markdownURL(r.CoverURL))
+		}
+		if r.Synopsis != "" {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(r.Synopsis))
+		}
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+		b.Reset()
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var statusLabels = map[string]string{
+	models.UserStatusWish:     "想玩",
+	models.UserStatusPlaying:  "在玩",
+	models.UserStatusFinished: "玩过",
+	models.UserStatusOnHold:   "搁置",
+	models.UserStatusDropped:  "抛弃",
+}
+
+func writeMarkdownItem(b *strings.Builder, label string, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "- %s: %s\n", label, markdownInline(value))
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+	"#", "\\#", "<", "&lt;", ">", "&gt;", "\r", "", "\n", " ",
+)
+
+func markdownInline(s string) string {
+	return markdownEscaper.Replace(strings.TrimSpace(s))
+}
+
+// 链接地址中的空格、括号和换行会提前结束链接, 改用百分号编码。
+var markdownURLEscaper = strings.NewReplacer(
+	" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", "\r", "", "\n", "",
+)
+
+func markdownURL(s string) string {
+	return markdownURLEscaper.Replace(strings.TrimSpace(s))
+}
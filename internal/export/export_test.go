@@ -0,0 +1,34 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://example.com/a.jpg", "https://example.com/a.jpg"},
+		{"https://example.com/a (1).jpg", "https://example.com/a%20%281%29.jpg"},
+		{"https://example.com/a.jpg)\n[x](javascript:alert(1)", "https://example.com/a.jpg%29[x]%28javascript:alert%281%29"},
+		{" https://example.com/<b>.jpg\r\n", "https://example.com/%3Cb%3E.jpg"},
+	}
+	for _, tt := range tests {
+		if got := markdownURL(tt.in); got != tt.want {
+			t.Errorf("markdownURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteMarkdownCover(t *testing.T) {
+	var b strings.Builder
+	records := []Record{{ID: 1, TitleJP: "タイトル", CoverURL: "https://example.com/cover (1).jpg"}}
+	if err := writeMarkdown(&b, records); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "![封面](https://example.com/cover%20%281%29.jpg)\n") {
+		t.Errorf("cover line not escaped:\n%s", b.String())
+	}
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"galgame-gui/internal/models"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const CalendarExtension = ".ics"
+
+// CalendarEvent 是日历中的一个全天事件。模糊日期的游戏放在时间段的第一天,
+// 并在标题中注明 "2026年春" 之类的原始精度, 避免日历里看起来像是确定的日期。
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Date        time.Time
+}
+
+// CalendarEventFromGame 为有发售日期的游戏生成日历事件, 日期未定或缺失时返回 false。
+func CalendarEventFromGame(game models.Galgame, userGame models.UserGame) (CalendarEvent, bool) {
+	release := game.ReleaseDate
+	if release.Precision == models.PrecisionNone || release.Precision == models.PrecisionUnknown {
+		return CalendarEvent{}, false
+	}
+
+	title := stringFromPtr(game.TitleCN)
+	if title == "" {
+		title = game.TitleJP
+	}
+	summary := title + " 发售"
+	if release.Precision != models.PrecisionDay {
+		summary += "（" + release.String() + "）"
+	}
+	if userGame.Status == models.UserStatusWish {
+		summary = "★ " + summary
+	}
+
+	var description []string
+	if title != game.TitleJP {
+		description = append(description, game.TitleJP)
+	}
+	if brand := stringFromPtr(game.Brand); brand != "" {
+		description = append(description, "品牌: "+brand)
+	}
+	description = append(description, "发售日期: "+release.String())
+	if label, ok := statusLabels[userGame.Status]; ok {
+		description = append(description, "状态: "+label)
+	}
+
+	return CalendarEvent{
+		UID:         fmt.Sprintf("game-%d@shirogal", game.ID),
+		Summary:     summary,
+		Description: strings.Join(description, "\n"),
+		Date:        release.Time,
+	}, true
+}
+
+// WriteCalendar 按 RFC 5545 输出 iCalendar 文件, UID 与游戏ID对应, 重复导入时日历应用会更新已有事件。
+func WriteCalendar(w io.Writer, events []CalendarEvent) error {
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//ShiroGal//Release Calendar//ZH")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + escapeICSText("ShiroGal 发售日历"))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeICSText(e.Description))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func WriteCalendarFile(path string, events []CalendarEvent) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建日历文件失败: %w", err)
+	}
+	if err := WriteCalendar(file, events); err != nil {
+		file.Close()
+		return fmt.Errorf("写入日历文件失败: %w", err)
+	}
+	return file.Close()
+}
+
+// 单独的 \r 也会被日历程序当作换行, 同样转义为 \n
+var icsTextReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeICSText(s string) string {
+	return icsTextReplacer.Replace(s)
+}
+
+// writeFolded 按 RFC 5545 把超过 75 字节的行折叠, 且不会从 UTF-8 字符中间断开。
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeICSText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"line1\r\nline2\nline3", `line1\nline2\nline3`},
+		{"cr\ronly", `cr\nonly`},
+		{"初回限定版; 特典付き", `初回限定版\; 特典付き`},
+	}
+	for _, tt := range tests {
+		if got := escapeICSText(tt.in); got != tt.want {
+			t.Errorf("escapeICSText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"short", "SUMMARY:short"},
+		{"exactly 75 bytes", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("恋愛アドベンチャー", 12)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			w := bufio.NewWriter(&b)
+			writeFolded(w, tt.in)
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output does not end with CRLF: %q", out)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			var unfolded strings.Builder
+			for i, line := range lines {
+				if len(line) > 75 {
+					t.Errorf("line %d is %d bytes, want at most 75", i, len(line))
+				}
+				if i > 0 {
+					if !strings.HasPrefix(line, " ") {
+						t.Fatalf("continuation line %d does not start with a space: %q", i, line)
+					}
+					line = line[1:]
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, line)
+				}
+				unfolded.WriteString(line)
+			}
+			if unfolded.String() != tt.in {
+				t.Errorf("unfolded = %q, want %q", unfolded.String(), tt.in)
+			}
+			if len(tt.in) <= 75 && len(lines) != 1 {
+				t.Errorf("a %d byte line was folded into %d lines", len(tt.in), len(lines))
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package imagecache
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RoutePrefix  = "/img/"
+	DefaultQuota = 512 << 20
+
+	maxImageSize = 32 << 20
+
+	// pinsFile 每行记录一个被固定的原图 key, 只追加, 加载时去重
+	pinsFile = "pinned.txt"
+)
+
+type entry struct {
+	key        string
+	size       int64
+	lastAccess time.Time
+	element    *list.Element
+}
+
+type inflight struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+// Cache 把远程图片缓存到本地目录, 以 URL 的哈希作为文件名, 超出配额时按最近最少使用淘汰。
+// 文件的修改时间记录最近一次访问, 因此重启后仍能恢复 LRU 顺序。
+type Cache struct {
+	dir     string
+	quota   int64
+	fetcher *Fetcher
+
+	mu       sync.Mutex
+	entries  map[string]*entry
+	lru      *list.List
+	total    int64
+	inflight map[string]*inflight
+	onStore  func(rawURL string, data []byte)
+
+	// urls 记录原图 key 对应的原始地址, 用于在缓存未命中时下载; 原图被淘汰时一并删除,
+	// 之后再展示这张图片时 URLFor 会重新登记
+	urls map[string]string
+	// sourceWidths 记录解码过的原图宽度, 不够宽的原图不必每次请求缩略图时都重新解码
+	sourceWidths map[string]int
+	// pinned 是预取后固定的原图 key, 它们和它们的缩略图不会被 LRU 淘汰
+	pinned map[string]struct{}
+}
+
+func New(dir string, quota int64) (*Cache, error) {
+	if quota <= 0 {
+		quota = DefaultQuota
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建图片缓存目录失败: %w", err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		quota:    quota,
+		fetcher:  NewFetcher(&http.Client{Timeout: 30 * time.Second}),
+		entries:  make(map[string]*entry),
+		lru:      list.New(),
+		urls:     make(map[string]string),
+		inflight: make(map[string]*inflight),
+
+		sourceWidths: make(map[string]int),
+		pinned:       make(map[string]struct{}),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func Key(rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func isCacheable(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+// isValidKey 接受原图的 key 以及 "<key>-w<宽度>" 形式的缩略图 key。
+func isValidKey(key string) bool {
+	base, width, hasWidth := strings.Cut(key, "-w")
+	if len(base) != sha1.Size*2 {
+		return false
+	}
+	if _, err := hex.DecodeString(base); err != nil {
+		return false
+	}
+	if hasWidth {
+		if _, err := strconv.Atoi(width); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// load 扫描缓存目录重建索引, 并清理上次异常退出留下的临时文件。
+func (c *Cache) load() error {
+	if err := c.loadPins(); err != nil {
+		return err
+	}
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("读取图片缓存目录失败: %w", err)
+	}
+
+	var loaded []*entry
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			_ = os.Remove(filepath.Join(c.dir, f.Name()))
+			continue
+		}
+		if !isValidKey(f.Name()) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		loaded = append(loaded, &entry{key: f.Name(), size: info.Size(), lastAccess: info.ModTime()})
+	}
+
+	// 按访问时间从新到旧插入, 使链表尾部为最久未使用的条目
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].lastAccess.After(loaded[j].lastAccess)
+	})
+	for _, e := range loaded {
+		e.element = c.lru.PushBack(e)
+		c.entries[e.key] = e
+		c.total += e.size
+	}
+	c.evictLocked("")
+	return nil
+}
+
+// URLFor 登记原始图片地址并返回对应的本地路径, 非 http(s) 地址原样返回。
+func (c *Cache) URLFor(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !isCacheable(rawURL) {
+		return rawURL
+	}
+	key := Key(rawURL)
+	c.mu.Lock()
+	c.urls[key] = rawURL
+	c.mu.Unlock()
+	return RoutePrefix + key
+}
+
+func (c *Cache) RewriteList(rawURLs string, sep string) string {
+	parts := strings.Split(rawURLs, sep)
+	for i, p := range parts {
+		parts[i] = c.URLFor(p)
+	}
+	return strings.Join(parts, sep)
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func (c *Cache) Usage() (files int, bytes int64, quota int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.total, c.quota
+}
+
+func (c *Cache) Has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+func (c *Cache) touch(key string) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		e.lastAccess = time.Now()
+		c.lru.MoveToFront(e.element)
+	}
+	c.mu.Unlock()
+	if ok {
+		now := time.Now()
+		_ = os.Chtimes(c.path(key), now, now)
+	}
+}
+
+// Ensure 保证原始地址对应的图片已在缓存中。
+func (c *Cache) Ensure(rawURL string) (string, error) {
+	if !isCacheable(rawURL) {
+		return "", fmt.Errorf("不支持缓存的图片地址: %s", rawURL)
+	}
+	key := Key(rawURL)
+
+	c.mu.Lock()
+	c.urls[key] = rawURL
+	c.mu.Unlock()
+	if c.Has(key) {
+		return key, nil
+	}
+	return key, c.do(key, func() error {
+		return c.download(key, rawURL)
+	})
+}
+
+// do 保证同一个 key 同时只有一个生成任务在执行, 其它调用者等待并共享结果。
+func (c *Cache) do(key string, fn func() error) error {
+	c.mu.Lock()
+	if _, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		return nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.err
+	}
+	call := &inflight{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.err = fn()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	call.wg.Done()
+	return call.err
+}
+
+func (c *Cache) Fetcher() *Fetcher {
+	return c.fetcher
+}
+
+// SetOnStore 设置原图下载入缓存后的回调, 回调在下载所在的 goroutine 中执行。
+func (c *Cache) SetOnStore(fn func(rawURL string, data []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onStore = fn
+}
+
+func (c *Cache) download(key string, rawURL string) error {
+	data, err := c.fetcher.Fetch(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := c.store(key, data); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	onStore := c.onStore
+	c.mu.Unlock()
+	if onStore != nil {
+		onStore(rawURL, data)
+	}
+	return nil
+}
+
+// Read 返回已缓存的原图数据, 不会触发下载。
+func (c *Cache) Read(rawURL string) ([]byte, bool) {
+	key := Key(rawURL)
+	if !c.Has(key) {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *Cache) store(key string, data []byte) error {
+	if len(data) > maxImageSize {
+		return fmt.Errorf("图片超过大小上限 (%d 字节)", maxImageSize)
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return fmt.Errorf("下载的内容不是图片")
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入图片缓存失败: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入图片缓存失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入图片缓存失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入图片缓存失败: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[key]; ok {
+		c.total -= old.size
+		c.lru.Remove(old.element)
+	}
+	e := &entry{key: key, size: int64(len(data)), lastAccess: time.Now()}
+	e.element = c.lru.PushFront(e)
+	c.entries[key] = e
+	c.total += e.size
+	c.evictLocked(key)
+	return nil
+}
+
+// evictLocked 从最久未使用的一端开始删除, 直到总大小回到配额以内。
+// keep 对应的条目和被固定的条目不会被删除, 因此固定的图片较多时总大小可能超出配额。
+func (c *Cache) evictLocked(keep string) {
+	for el := c.lru.Back(); el != nil && c.total > c.quota; {
+		e := el.Value.(*entry)
+		prev := el.Prev()
+		if e.key == keep || c.isPinnedLocked(e.key) {
+			el = prev
+			continue
+		}
+		if err := os.Remove(c.path(e.key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("图片缓存：删除 %s 失败: %v", e.key, err)
+			return
+		}
+		c.lru.Remove(el)
+		delete(c.entries, e.key)
+		delete(c.sourceWidths, e.key)
+		delete(c.urls, e.key)
+		c.total -= e.size
+		el = prev
+	}
+}
+
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, RoutePrefix)
+	if !isValidKey(key) || strings.Contains(key, "-w") {
+		http.NotFound(w, r)
+		return
+	}
+
+	width, err := strconv.Atoi(r.URL.Query().Get("w"))
+	if err != nil || width < 0 {
+		width = 0
+	}
+	if width > 0 {
+		width = snapThumbnailWidth(width)
+		// 缩略图已缓存时直接返回, 原图被淘汰且离线时也不会退回占位图
+		if thumbKey := thumbnailKey(key, width); c.Has(thumbKey) {
+			c.serveFile(w, r, thumbKey)
+			return
+		}
+	}
+
+	if !c.Has(key) {
+		c.mu.Lock()
+		rawURL, ok := c.urls[key]
+		c.mu.Unlock()
+		if !ok {
+			servePlaceholder(w)
+			return
+		}
+		if _, err := c.Ensure(rawURL); err != nil {
+			log.Printf("图片缓存：获取 %s 失败, 返回占位图: %v", rawURL, err)
+			servePlaceholder(w)
+			return
+		}
+	}
+
+	if width > 0 {
+		thumbKey, err := c.ensureThumbnail(key, width)
+		if err != nil {
+			log.Printf("图片缓存：生成缩略图失败, 返回原图: %v", err)
+		} else {
+			key = thumbKey
+		}
+	}
+	c.serveFile(w, r, key)
+}
+
+func (c *Cache) serveFile(w http.ResponseWriter, r *http.Request, key string) {
+	file, err := os.Open(c.path(key))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.touch(key)
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
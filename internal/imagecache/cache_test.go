@@ -0,0 +1,37 @@
+package imagecache
+
+import "testing"
+
+func TestEvictionForgetsOriginalURL(t *testing.T) {
+	c := newTestCache(t)
+	oldURL, newURL := "https://example.com/old.png", "https://example.com/new.png"
+	c.URLFor(oldURL)
+	old := storePNG(t, c, oldURL, 100)
+
+	// 把配额降到只能放下一张图片, 存入新图片时淘汰旧图片
+	c.mu.Lock()
+	c.quota = c.total
+	c.mu.Unlock()
+	c.URLFor(newURL)
+	fresh := storePNG(t, c, newURL, 100)
+
+	c.mu.Lock()
+	_, oldKnown := c.urls[old]
+	_, freshKnown := c.urls[fresh]
+	c.mu.Unlock()
+	if c.Has(old) || oldKnown {
+		t.Errorf("evicted original: cached = %v, url registered = %v, want neither", c.Has(old), oldKnown)
+	}
+	if !freshKnown {
+		t.Error("URL of the image kept in the cache was dropped")
+	}
+
+	// 再次展示时重新登记
+	c.URLFor(oldURL)
+	c.mu.Lock()
+	got := c.urls[old]
+	c.mu.Unlock()
+	if got != oldURL {
+		t.Errorf("re-registered URL = %q, want %q", got, oldURL)
+	}
+}
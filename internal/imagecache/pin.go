@@ -0,0 +1,88 @@
+package imagecache
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Pin 固定原始地址对应的图片, 被固定的原图及其缩略图不会被 LRU 淘汰, 重启后仍然有效。
+func (c *Cache) Pin(rawURL string) error {
+	key := Key(rawURL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pinned[key]; ok {
+		return nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(c.dir, pinsFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("保存固定的图片失败: %w", err)
+	}
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("保存固定的图片失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("保存固定的图片失败: %w", err)
+	}
+	c.pinned[key] = struct{}{}
+	return nil
+}
+
+// UnpinAll 取消所有固定, 之后超出配额的部分会立即按 LRU 淘汰。
+func (c *Cache) UnpinAll() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(filepath.Join(c.dir, pinsFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("取消固定图片失败: %w", err)
+	}
+	c.pinned = make(map[string]struct{})
+	c.evictLocked("")
+	return nil
+}
+
+// Pinned 返回已缓存的固定图片 (包括缩略图) 的数量和总大小。
+func (c *Cache) Pinned() (files int, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if c.isPinnedLocked(key) {
+			files++
+			bytes += e.size
+		}
+	}
+	return files, bytes
+}
+
+// isPinnedLocked 判断 key 是否属于被固定的原图, 缩略图跟随原图。
+func (c *Cache) isPinnedLocked(key string) bool {
+	base, _, _ := strings.Cut(key, "-w")
+	_, ok := c.pinned[base]
+	return ok
+}
+
+func (c *Cache) loadPins() error {
+	f, err := os.Open(filepath.Join(c.dir, pinsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取固定的图片失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key := strings.TrimSpace(scanner.Text())
+		if isValidKey(key) && !strings.Contains(key, "-w") {
+			c.pinned[key] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取固定的图片失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package imagecache
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestPinnedImagesSurviveEviction(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	size := int64(len(data))
+
+	dir := t.TempDir()
+	c, err := New(dir, 2*size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinnedURL := "https://example.com/pinned.png"
+	pinned := Key(pinnedURL)
+	if err := c.store(pinned, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Pin(pinnedURL); err != nil {
+		t.Fatal(err)
+	}
+	thumb := thumbnailKey(pinned, 160)
+	if err := c.store(thumb, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// 之后写入的图片把缓存撑过配额, 最久未使用的固定图片也不能被淘汰
+	for _, u := range []string{"https://example.com/a.png", "https://example.com/b.png", "https://example.com/c.png"} {
+		if err := c.store(Key(u), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !c.Has(pinned) || !c.Has(thumb) {
+		t.Fatal("pinned image or its thumbnail was evicted")
+	}
+	if files, bytes := c.Pinned(); files != 2 || bytes != 2*size {
+		t.Errorf("Pinned() = %d, %d, want 2, %d", files, bytes, 2*size)
+	}
+	if !c.Has(Key("https://example.com/c.png")) {
+		t.Error("the newest image should be kept")
+	}
+
+	// 重启后固定仍然有效
+	reopened, err := New(dir, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Has(pinned) || !reopened.Has(thumb) {
+		t.Fatal("pin was lost after reopening the cache")
+	}
+
+	if err := reopened.UnpinAll(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _, _ := reopened.Usage(); files != 1 {
+		t.Errorf("after UnpinAll the cache holds %d files, want 1", files)
+	}
+	if files, _ := reopened.Pinned(); files != 0 {
+		t.Errorf("after UnpinAll %d files are still pinned", files)
+	}
+}
@@ -0,0 +1,182 @@
+package imagecache
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+const DefaultPrefetchConcurrency = 4
+
+type PrefetchStatus struct {
+	Running bool   `json:"running"`
+	Paused  bool   `json:"paused"`
+	Total   int    `json:"total"`
+	Done    int    `json:"done"`
+	Failed  int    `json:"failed"`
+	Pending int    `json:"pending"`
+	Files   int    `json:"files"`
+	Bytes   int64  `json:"bytes"`
+	Quota   int64  `json:"quota"`
+	LastErr string `json:"last_error,omitempty"`
+	// 预取的图片会被固定, 不参与 LRU 淘汰
+	PinnedFiles int   `json:"pinned_files"`
+	PinnedBytes int64 `json:"pinned_bytes"`
+}
+
+// Prefetcher 在后台把一批图片下载进缓存并固定, 同一时间只有一个任务, 运行中再次 Enqueue 会追加到队列。
+type Prefetcher struct {
+	cache       *Cache
+	concurrency int
+	onProgress  func(PrefetchStatus)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []string
+	queued   map[string]struct{}
+	workers  int
+	paused   bool
+	canceled bool
+	total    int
+	done     int
+	failed   int
+	lastErr  string
+}
+
+func NewPrefetcher(cache *Cache, concurrency int, onProgress func(PrefetchStatus)) *Prefetcher {
+	if concurrency <= 0 {
+		concurrency = DefaultPrefetchConcurrency
+	}
+	p := &Prefetcher{
+		cache:       cache,
+		concurrency: concurrency,
+		onProgress:  onProgress,
+		queued:      make(map[string]struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+func (p *Prefetcher) Enqueue(urls []string) PrefetchStatus {
+	p.mu.Lock()
+	if p.workers == 0 {
+		p.total, p.done, p.failed, p.lastErr = 0, 0, 0, ""
+		p.queued = make(map[string]struct{})
+	}
+	p.canceled = false
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if !isCacheable(u) {
+			continue
+		}
+		if _, ok := p.queued[u]; ok {
+			continue
+		}
+		p.queued[u] = struct{}{}
+		p.queue = append(p.queue, u)
+		p.total++
+	}
+	for p.workers < p.concurrency && p.workers < len(p.queue) {
+		p.workers++
+		go p.work()
+	}
+	p.mu.Unlock()
+
+	status := p.Status()
+	p.report(status)
+	return status
+}
+
+func (p *Prefetcher) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	p.report(p.Status())
+}
+
+func (p *Prefetcher) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.report(p.Status())
+}
+
+// Cancel 清空队列, 正在下载的图片会继续完成。
+func (p *Prefetcher) Cancel() {
+	p.mu.Lock()
+	p.canceled = true
+	p.queue = nil
+	p.paused = false
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.report(p.Status())
+}
+
+func (p *Prefetcher) Status() PrefetchStatus {
+	files, bytes, quota := p.cache.Usage()
+	pinnedFiles, pinnedBytes := p.cache.Pinned()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PrefetchStatus{
+		Running: p.workers > 0,
+		Paused:  p.paused,
+		Total:   p.total,
+		Done:    p.done,
+		Failed:  p.failed,
+		Pending: len(p.queue),
+		Files:   files,
+		Bytes:   bytes,
+		Quota:   quota,
+		LastErr: p.lastErr,
+
+		PinnedFiles: pinnedFiles,
+		PinnedBytes: pinnedBytes,
+	}
+}
+
+func (p *Prefetcher) report(status PrefetchStatus) {
+	if p.onProgress != nil {
+		p.onProgress(status)
+	}
+}
+
+func (p *Prefetcher) work() {
+	for {
+		p.mu.Lock()
+		for p.paused && !p.canceled {
+			p.cond.Wait()
+		}
+		if p.canceled || len(p.queue) == 0 {
+			p.workers--
+			finished := p.workers == 0
+			p.mu.Unlock()
+			if finished {
+				p.report(p.Status())
+			}
+			return
+		}
+		rawURL := p.queue[0]
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		_, err := p.cache.Ensure(rawURL)
+		if err == nil {
+			// 预取是为了离线浏览, 固定后不会被日常浏览挤出缓存
+			err = p.cache.Pin(rawURL)
+		}
+
+		p.mu.Lock()
+		if err != nil {
+			p.failed++
+			p.lastErr = err.Error()
+		} else {
+			p.done++
+		}
+		p.mu.Unlock()
+		if err != nil {
+			log.Printf("图片预取：%s 失败: %v", rawURL, err)
+		}
+		p.report(p.Status())
+	}
+}
@@ -0,0 +1,224 @@
+package imagecache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+// HostRule 描述访问某个图片站点时需要附加的请求头和可替换的镜像域名。
+// Referer 为空时使用图片所在站点的根地址, 为 "-" 时不发送 Referer。
+type HostRule struct {
+	Referer   string            `json:"referer,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Mirrors   []string          `json:"mirrors,omitempty"`
+}
+
+// DefaultHostRules 是已知有防盗链检查的图片站点, 每个 Fetcher 创建时都会登记这些规则,
+// 用户在设置中保存的同名规则会覆盖它们。
+var DefaultHostRules = map[string]HostRule{
+	"i.pximg.net":    {Referer: "https://www.pixiv.net/"},
+	"img.dlsite.jp":  {Referer: "https://www.dlsite.com/"},
+	"www.getchu.com": {Referer: "https://www.getchu.com/", Headers: map[string]string{"Cookie": "getchu_adalt_flag=getchu.com"}},
+}
+
+type Fetcher struct {
+	client     *http.Client
+	retries    int
+	retryDelay time.Duration
+
+	mu    sync.RWMutex
+	rules map[string]HostRule
+}
+
+func NewFetcher(client *http.Client) *Fetcher {
+	f := &Fetcher{
+		client:     client,
+		retries:    3,
+		retryDelay: 500 * time.Millisecond,
+	}
+	f.SetHostRules(nil)
+	return f
+}
+
+func (f *Fetcher) SetHostRule(host string, rule HostRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[strings.ToLower(host)] = rule
+}
+
+// SetHostRules 用默认规则加上 overrides 替换全部规则, overrides 中的站点覆盖同名的默认规则。
+func (f *Fetcher) SetHostRules(overrides map[string]HostRule) {
+	rules := make(map[string]HostRule, len(DefaultHostRules)+len(overrides))
+	for host, rule := range DefaultHostRules {
+		rules[host] = rule
+	}
+	for host, rule := range overrides {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			rules[host] = rule
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = rules
+}
+
+func (f *Fetcher) rule(host string) HostRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.rules[strings.ToLower(host)]
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.code)
+}
+
+// retryable 判断失败是否值得重试: 网络错误、5xx 和 429 会重试, 其它 4xx 直接换镜像。
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Fetch 依次尝试原始地址和镜像地址, 每个地址按需重试, 返回第一份成功下载的图片。
+func (f *Fetcher) Fetch(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的图片地址: %w", err)
+	}
+	rule := f.rule(u.Hostname())
+
+	targets := []*url.URL{u}
+	for _, mirror := range rule.Mirrors {
+		m := *u
+		m.Host = mirror
+		targets = append(targets, &m)
+	}
+
+	var lastErr error
+	for _, target := range targets {
+		for attempt := 0; attempt < f.retries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(f.retryDelay * time.Duration(1<<(attempt-1)))
+			}
+			data, err := f.fetchOnce(target, rule)
+			if err == nil {
+				return data, nil
+			}
+			lastErr = fmt.Errorf("%s: %w", target.Host, err)
+			if !retryable(err) {
+				break
+			}
+		}
+	}
+	return nil, fmt.Errorf("下载图片失败: %w", lastErr)
+}
+
+func (f *Fetcher) fetchOnce(target *url.URL, rule HostRule) ([]byte, error) {
+	req, err := http.NewRequest("GET", target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := rule.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+	switch rule.Referer {
+	case "":
+		req.Header.Set("Referer", target.Scheme+"://"+target.Host+"/")
+	case "-":
+	default:
+		req.Header.Set("Referer", rule.Referer)
+	}
+	for k, v := range rule.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &statusError{code: res.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("图片超过大小上限 (%d 字节)", maxImageSize)
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		// 防盗链页面通常返回 200 的 HTML, 当作不可重试的失败处理以便尝试镜像
+		return nil, &statusError{code: http.StatusForbidden}
+	}
+	return data, nil
+}
+
+var (
+	placeholderOnce sync.Once
+	placeholderPNG  []byte
+)
+
+// placeholder 生成一张 3:4 的灰色占位图, 用于源站不可达时代替破图。
+func placeholder() []byte {
+	placeholderOnce.Do(func() {
+		const width, height = 300, 400
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		background := color.RGBA{R: 0xe9, G: 0xec, B: 0xef, A: 0xff}
+		stripe := color.RGBA{R: 0xde, G: 0xe2, B: 0xe6, A: 0xff}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				c := background
+				if (x+y)/20%2 == 0 {
+					c = stripe
+				}
+				img.Set(x, y, c)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err == nil {
+			placeholderPNG = buf.Bytes()
+		}
+	})
+	return placeholderPNG
+}
+
+func servePlaceholder(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Image-Fallback", "placeholder")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(placeholder())
+}
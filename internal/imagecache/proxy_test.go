@@ -0,0 +1,61 @@
+package imagecache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSetHostRulesKeepsDefaults(t *testing.T) {
+	f := NewFetcher(http.DefaultClient)
+	for host, want := range DefaultHostRules {
+		if got := f.rule(host); got.Referer != want.Referer {
+			t.Errorf("default rule for %s: Referer = %q, want %q", host, got.Referer, want.Referer)
+		}
+	}
+
+	f.SetHostRules(map[string]HostRule{
+		" I.PXIMG.NET ": {Referer: "-"},
+		"example.com":   {UserAgent: "test"},
+	})
+	if got := f.rule("i.pximg.net").Referer; got != "-" {
+		t.Errorf("override for i.pximg.net: Referer = %q, want -", got)
+	}
+	if got := f.rule("example.com").UserAgent; got != "test" {
+		t.Errorf("rule for example.com: UserAgent = %q, want test", got)
+	}
+
+	// 再次设置时, 不在新规则中的站点回到默认规则或没有规则
+	f.SetHostRules(nil)
+	if got, want := f.rule("i.pximg.net").Referer, DefaultHostRules["i.pximg.net"].Referer; got != want {
+		t.Errorf("after reset: Referer = %q, want %q", got, want)
+	}
+	if got := f.rule("example.com").UserAgent; got != "" {
+		t.Errorf("after reset: example.com UserAgent = %q, want empty", got)
+	}
+}
+
+func TestFetchAppliesHostRule(t *testing.T) {
+	var referer, cookie string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referer, cookie = r.Header.Get("Referer"), r.Header.Get("Cookie")
+		_, _ = w.Write(placeholder())
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFetcher(srv.Client())
+	f.SetHostRules(map[string]HostRule{
+		u.Hostname(): {Referer: "https://ref.example/", Headers: map[string]string{"Cookie": "a=b"}},
+	})
+	if _, err := f.Fetch(srv.URL + "/cover.png"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if referer != "https://ref.example/" || cookie != "a=b" {
+		t.Errorf("Referer = %q, Cookie = %q", referer, cookie)
+	}
+}
@@ -0,0 +1,128 @@
+package imagecache
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ThumbnailWidths 是可用的缩略图宽度, 请求的宽度会向上取到最接近的一档, 避免为任意尺寸生成文件。
+var ThumbnailWidths = []int{160, 320, 640}
+
+// 小图的压缩痕迹不明显, 用较低的质量换取体积; 大图保留更多细节。
+var thumbnailQuality = map[int]int{
+	160: 70,
+	320: 78,
+	640: 85,
+}
+
+const GridThumbnailWidth = 320
+
+// maxThumbnailSourcePixels 限制生成缩略图时解码的原图像素数。maxImageSize 只限制文件大小,
+// 压缩率极高的图片解码后仍可能占用数 GB 内存; 超出的原图不生成缩略图, 直接返回原图。
+const maxThumbnailSourcePixels = 40_000_000
+
+func snapThumbnailWidth(requested int) int {
+	for _, w := range ThumbnailWidths {
+		if requested <= w {
+			return w
+		}
+	}
+	return ThumbnailWidths[len(ThumbnailWidths)-1]
+}
+
+func thumbnailKey(key string, width int) string {
+	return key + "-w" + strconv.Itoa(width)
+}
+
+// ThumbnailURLFor 和 URLFor 相同, 但附带宽度参数, 让图片路由返回缩略图。
+func (c *Cache) ThumbnailURLFor(rawURL string, width int) string {
+	local := c.URLFor(rawURL)
+	if !strings.HasPrefix(local, RoutePrefix) {
+		return local
+	}
+	return local + "?w=" + strconv.Itoa(snapThumbnailWidth(width))
+}
+
+// ensureThumbnail 从已缓存的原图生成指定宽度的 JPEG 缩略图; 原图不比目标宽时直接返回原图的 key。
+func (c *Cache) ensureThumbnail(key string, width int) (string, error) {
+	thumbKey := thumbnailKey(key, width)
+	if c.Has(thumbKey) {
+		return thumbKey, nil
+	}
+	if c.isNarrow(key, width) {
+		return key, nil
+	}
+
+	err := c.do(thumbKey, func() error {
+		data, err := os.ReadFile(c.path(key))
+		if err != nil {
+			return fmt.Errorf("读取原图失败: %w", err)
+		}
+		// 先只读取图片头, 原图不比目标宽时不必完整解码
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("解码图片失败: %w", err)
+		}
+		c.setSourceWidth(key, config.Width)
+		if config.Width <= width {
+			return nil
+		}
+		if int64(config.Width)*int64(config.Height) > maxThumbnailSourcePixels {
+			return fmt.Errorf("原图尺寸 %dx%d 过大, 不生成缩略图", config.Width, config.Height)
+		}
+		src, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("解码图片失败: %w", err)
+		}
+
+		bounds := src.Bounds()
+		height := bounds.Dy() * width / bounds.Dx()
+		if height < 1 {
+			height = 1
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		// JPEG 不支持透明, 先铺白底
+		stddraw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, stddraw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality[width]}); err != nil {
+			return fmt.Errorf("编码缩略图失败: %w", err)
+		}
+		return c.store(thumbKey, buf.Bytes())
+	})
+	if err != nil {
+		return "", err
+	}
+	// 等待其它请求生成的调用者也按实际结果返回: 生成了缩略图就用缩略图, 否则原图不够宽
+	if c.Has(thumbKey) {
+		return thumbKey, nil
+	}
+	return key, nil
+}
+
+// isNarrow 判断原图是否已知不比 width 宽。
+func (c *Cache) isNarrow(key string, width int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w, ok := c.sourceWidths[key]
+	return ok && w <= width
+}
+
+func (c *Cache) setSourceWidth(key string, width int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sourceWidths[key] = width
+}
@@ -0,0 +1,156 @@
+package imagecache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	c, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// storePNG 把一张指定宽度的 PNG 作为原图写入缓存, 返回它的 key。
+func storePNG(t *testing.T, c *Cache, rawURL string, width int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, width))); err != nil {
+		t.Fatal(err)
+	}
+	key := Key(rawURL)
+	if err := c.store(key, buf.Bytes()); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	return key
+}
+
+func TestEnsureThumbnailConcurrentCallers(t *testing.T) {
+	c := newTestCache(t)
+	narrow := storePNG(t, c, "https://example.com/narrow.png", 100)
+	wide := storePNG(t, c, "https://example.com/wide.png", 400)
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"narrow original", narrow, narrow},
+		{"wide original", wide, thumbnailKey(wide, 160)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			results := make([]string, 8)
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					key, err := c.ensureThumbnail(tt.key, 160)
+					if err != nil {
+						t.Errorf("ensureThumbnail: %v", err)
+					}
+					results[i] = key
+				}(i)
+			}
+			wg.Wait()
+			for i, got := range results {
+				if got != tt.want {
+					t.Errorf("caller %d got %q, want %q", i, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnsureThumbnailRemembersNarrowOriginal(t *testing.T) {
+	c := newTestCache(t)
+	key := storePNG(t, c, "https://example.com/narrow.png", 100)
+	if _, err := c.ensureThumbnail(key, 320); err != nil {
+		t.Fatal(err)
+	}
+
+	// 原图文件损坏后仍能返回原图的 key, 说明没有再次解码
+	if err := os.WriteFile(c.path(key), []byte("broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ensureThumbnail(key, 640)
+	if err != nil {
+		t.Fatalf("ensureThumbnail: %v", err)
+	}
+	if got != key {
+		t.Errorf("got %q, want original key %q", got, key)
+	}
+}
+
+func TestServeCachedThumbnailWithoutOriginal(t *testing.T) {
+	c := newTestCache(t)
+	key := storePNG(t, c, "https://example.com/wide.png", 400)
+	thumbKey, err := c.ensureThumbnail(key, 320)
+	if err != nil || thumbKey != thumbnailKey(key, 320) {
+		t.Fatalf("ensureThumbnail = %q, %v", thumbKey, err)
+	}
+
+	// 模拟原图被淘汰, 并且没有登记原始地址 (相当于离线无法重新下载)
+	c.mu.Lock()
+	e := c.entries[key]
+	c.lru.Remove(e.element)
+	delete(c.entries, key)
+	c.total -= e.size
+	c.mu.Unlock()
+	if err := os.Remove(c.path(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", RoutePrefix+key+"?w=300", nil))
+	if rec.Header().Get("X-Image-Fallback") != "" {
+		t.Fatal("served the placeholder instead of the cached thumbnail")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+}
+
+// pngHeader 返回只有签名和 IHDR 的 PNG, 声明的尺寸可以任意大, 用于模拟解压炸弹。
+func pngHeader(width, height uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	chunk := make([]byte, 0, 17)
+	chunk = append(chunk, "IHDR"...)
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	// 8 位 RGBA, 默认压缩、过滤, 不隔行
+	chunk = append(chunk, 8, 6, 0, 0, 0)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(chunk)-4))
+	buf.Write(chunk)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestEnsureThumbnailRejectsOversizedSource(t *testing.T) {
+	c := newTestCache(t)
+	key := Key("https://example.com/bomb.png")
+	if err := c.store(key, pngHeader(50000, 50000)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.ensureThumbnail(key, 320)
+	if err == nil || !strings.Contains(err.Error(), "过大") {
+		t.Fatalf("ensureThumbnail error = %v, want the pixel budget to reject it before decoding", err)
+	}
+	if c.Has(thumbnailKey(key, 320)) {
+		t.Error("a thumbnail was stored for the oversized source")
+	}
+}
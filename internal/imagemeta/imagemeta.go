@@ -0,0 +1,144 @@
+package imagemeta
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"sort"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	sampleSize  = 32
+	xComponents = 4
+	yComponents = 3
+)
+
+// Meta 是封面的占位信息: 用于渐进显示的 blurhash, 以及主色和点缀色 (#rrggbb)。
+type Meta struct {
+	Blurhash      string `json:"blurhash"`
+	DominantColor string `json:"dominant_color"`
+	AccentColor   string `json:"accent_color"`
+}
+
+func Analyze(data []byte) (Meta, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Meta{}, fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return Meta{}, fmt.Errorf("图片尺寸无效")
+	}
+	width, height := sampleSize, sampleSize*bounds.Dy()/bounds.Dx()
+	if bounds.Dy() > bounds.Dx() {
+		width, height = sampleSize*bounds.Dx()/bounds.Dy(), sampleSize
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	sample := image.NewRGBA(image.Rect(0, 0, width, height))
+	stddraw.Draw(sample, sample.Bounds(), image.NewUniform(color.White), image.Point{}, stddraw.Src)
+	draw.ApproxBiLinear.Scale(sample, sample.Bounds(), src, bounds, draw.Over, nil)
+
+	dominant, accent := palette(sample)
+	return Meta{
+		Blurhash:      encodeBlurhash(sample, xComponents, yComponents),
+		DominantColor: dominant,
+		AccentColor:   accent,
+	}, nil
+}
+
+type bucket struct {
+	count      int
+	r, g, b    int
+	saturation float64
+	hue        float64
+}
+
+// palette 把像素量化到每通道 4 位的色桶, 像素最多的桶为主色;
+// 点缀色取饱和度足够且色相与主色明显不同的最大桶, 找不到时退回主色。
+func palette(img *image.RGBA) (string, string) {
+	buckets := make(map[int]*bucket)
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+		key := (r>>4)<<8 | (g>>4)<<4 | b>>4
+		bk, ok := buckets[key]
+		if !ok {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.count++
+		bk.r += r
+		bk.g += g
+		bk.b += b
+	}
+
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		bk.r, bk.g, bk.b = bk.r/bk.count, bk.g/bk.count, bk.b/bk.count
+		bk.hue, bk.saturation = hueSaturation(bk.r, bk.g, bk.b)
+		sorted = append(sorted, bk)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].r<<16|sorted[i].g<<8|sorted[i].b < sorted[j].r<<16|sorted[j].g<<8|sorted[j].b
+	})
+
+	dominant := sorted[0]
+	accent := dominant
+	for _, bk := range sorted[1:] {
+		if bk.saturation < 0.35 {
+			continue
+		}
+		diff := math.Abs(bk.hue - dominant.hue)
+		if diff > 180 {
+			diff = 360 - diff
+		}
+		if dominant.saturation < 0.2 || diff >= 30 {
+			accent = bk
+			break
+		}
+	}
+	return hexColor(dominant), hexColor(accent)
+}
+
+func hueSaturation(r, g, b int) (float64, float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	maxC := math.Max(rf, math.Max(gf, bf))
+	minC := math.Min(rf, math.Min(gf, bf))
+	delta := maxC - minC
+	if delta == 0 {
+		return 0, 0
+	}
+
+	saturation := delta / maxC
+	var hue float64
+	switch maxC {
+	case rf:
+		hue = math.Mod((gf-bf)/delta, 6)
+	case gf:
+		hue = (bf-rf)/delta + 2
+	default:
+		hue = (rf-gf)/delta + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+	return hue, saturation
+}
+
+func hexColor(bk *bucket) string {
+	return fmt.Sprintf("#%02x%02x%02x", bk.r, bk.g, bk.b)
+}
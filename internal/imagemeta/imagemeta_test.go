@@ -0,0 +1,106 @@
+package imagemeta
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encodePNG 生成一张按 fill 着色的小图, 作为固定的测试输入。
+func encodePNG(t *testing.T, width, height int, fill func(x, y int) color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// 期望值是固定输入下的编码结果, 修改算法时需要重新核对; 纯色图片的 AC 分量不为零是 blurhash 参考算法本身的特性
+// (奇数分量的余弦基在一行上的和不为零), 与参考实现一致。
+func TestAnalyzeGolden(t *testing.T) {
+	red := color.NRGBA{R: 0xff, A: 0xff}
+	gray := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	blue := color.NRGBA{R: 0x20, G: 0x40, B: 0xe0, A: 0xff}
+
+	tests := []struct {
+		name string
+		data []byte
+		want Meta
+	}{
+		{
+			name: "纯色",
+			data: encodePNG(t, 16, 16, func(x, y int) color.Color { return red }),
+			want: Meta{Blurhash: "L9TI:j|cfQ|c|co1fQo1fQfQfQfQ", DominantColor: "#ff0000", AccentColor: "#ff0000"},
+		},
+		{
+			name: "灰底蓝色点缀",
+			data: encodePNG(t, 40, 30, func(x, y int) color.Color {
+				if x >= 30 {
+					return blue
+				}
+				return gray
+			}),
+			want: Meta{Blurhash: "LZD0QY-uI#t0ogj=a$j?fQfQfQfQ", DominantColor: "#808080", AccentColor: "#2040e0"},
+		},
+		{
+			name: "竖版渐变",
+			data: encodePNG(t, 12, 40, func(x, y int) color.Color {
+				return color.NRGBA{R: uint8(y * 6), G: 0x60, B: uint8(255 - y*6), A: 0xff}
+			}),
+			want: Meta{Blurhash: "L.G7Zss=fQs=6;SPfQSPw%jvfQjv", DominantColor: "#0760f7", AccentColor: "#9a6064"},
+		},
+		{
+			name: "透明像素按白色背景处理",
+			data: encodePNG(t, 8, 8, func(x, y int) color.Color { return color.NRGBA{} }),
+			want: Meta{Blurhash: "L9TSUA~qfQ~q~qoffQoffQfQfQfQ", DominantColor: "#ffffff", AccentColor: "#ffffff"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Analyze(tt.data)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Analyze() = %+v, want %+v", got, tt.want)
+			}
+			// 4x3 个分量: 1 位大小 + 1 位最大值 + 4 位 DC + 11 个 AC 各 2 位
+			if len(got.Blurhash) != 28 {
+				t.Errorf("blurhash %q has length %d, want 28", got.Blurhash, len(got.Blurhash))
+			}
+		})
+	}
+}
+
+func TestAnalyzeRejectsInvalidData(t *testing.T) {
+	if _, err := Analyze([]byte("not an image")); err == nil {
+		t.Error("Analyze(garbage) returned no error")
+	}
+}
+
+func TestEncodeBase83(t *testing.T) {
+	tests := []struct {
+		value, length int
+		want          string
+	}{
+		{0, 1, "0"},
+		{82, 1, "~"},
+		{83, 2, "10"},
+		{21, 1, "L"},
+		{83*83 - 1, 2, "~~"},
+	}
+	for _, tt := range tests {
+		if got := encodeBase83(tt.value, tt.length); got != tt.want {
+			t.Errorf("encodeBase83(%d, %d) = %q, want %q", tt.value, tt.length, got, tt.want)
+		}
+	}
+}
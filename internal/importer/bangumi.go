@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"galgame-gui/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Bangumi 收藏类型: 1 想玩, 2 玩过, 3 在玩, 4 搁置, 5 抛弃
+var bangumiTypeStatus = map[int]string{
+	1: models.UserStatusWish,
+	2: models.UserStatusFinished,
+	3: models.UserStatusPlaying,
+	4: models.UserStatusOnHold,
+	5: models.UserStatusDropped,
+}
+
+type bangumiItem struct {
+	SubjectID json.Number `json:"subject_id"`
+	Type      int         `json:"type"`
+	Rate      float64     `json:"rate"`
+	Comment   *string     `json:"comment"`
+	Subject   struct {
+		Name   string `json:"name"`
+		NameCN string `json:"name_cn"`
+		Date   string `json:"date"`
+	} `json:"subject"`
+}
+
+// parseBangumiJSON 支持 /v0/users/{username}/collections 的分页响应 ({"data": [...]}) 和裸数组。
+func parseBangumiJSON(data []byte) ([]Entry, error) {
+	var items []bangumiItem
+	trimmed := stripBOM(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("解析Bangumi导出文件失败: %w", err)
+		}
+	} else {
+		var wrapper struct {
+			Data []bangumiItem `json:"data"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapper); err != nil {
+			return nil, fmt.Errorf("解析Bangumi导出文件失败: %w", err)
+		}
+		items = wrapper.Data
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		entry := Entry{
+			ExternalID:  item.SubjectID.String(),
+			Title:       strings.TrimSpace(item.Subject.Name),
+			AltTitle:    strings.TrimSpace(item.Subject.NameCN),
+			ReleaseDate: item.Subject.Date,
+			Status:      bangumiTypeStatus[item.Type],
+			Rating:      clampRating(item.Rate),
+		}
+		if _, err := strconv.ParseInt(entry.ExternalID, 10, 64); err == nil {
+			entry.ExternalID = "bgm" + entry.ExternalID
+		}
+		if item.Comment != nil {
+			entry.Notes = strings.TrimSpace(*item.Comment)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SourceVNDB    = "vndb"
+	SourceBangumi = "bangumi"
+)
+
+// Entry 是从外部导出文件中读出的一条个人记录, 状态已映射为 models 中的游玩状态。
+type Entry struct {
+	Source      string `json:"source"`
+	ExternalID  string `json:"external_id"`
+	Title       string `json:"title"`
+	AltTitle    string `json:"alt_title"`
+	Brand       string `json:"brand"`
+	ReleaseDate string `json:"release_date"`
+	Status      string `json:"status"`
+	Rating      int    `json:"rating"`
+	Notes       string `json:"notes"`
+}
+
+func ParseFile(source string, path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+
+	var entries []Entry
+	switch source {
+	case SourceVNDB:
+		if strings.EqualFold(filepath.Ext(path), ".xml") || looksLikeXML(data) {
+			entries, err = parseVNDBXML(data)
+		} else {
+			entries, err = parseVNDBJSON(data)
+		}
+	case SourceBangumi:
+		entries, err = parseBangumiJSON(data)
+	default:
+		return nil, fmt.Errorf("不支持的导入来源: %s", source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Source = source
+	}
+	return entries, nil
+}
+
+func stripBOM(data []byte) []byte {
+	return bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+}
+
+func looksLikeXML(data []byte) bool {
+	return bytes.HasPrefix(stripBOM(data), []byte("<"))
+}
+
+func clampRating(rating float64) int {
+	r := int(rating + 0.5)
+	if r < 0 {
+		return 0
+	}
+	if r > 10 {
+		return 10
+	}
+	return r
+}
@@ -0,0 +1,268 @@
+package importer
+
+import (
+	"galgame-gui/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// 置信度不低于 AutoMatchThreshold 且明显领先第二名时直接采用, 介于两者之间的需要用户确认。
+	AutoMatchThreshold   = 0.9
+	ReviewMatchThreshold = 0.5
+
+	minTitleScore = 0.4
+	maxCandidates = 3
+)
+
+const (
+	DecisionAuto   = "auto"
+	DecisionReview = "review"
+	DecisionNone   = "none"
+)
+
+type Candidate struct {
+	GameID      int64   `json:"game_id"`
+	TitleJP     string  `json:"title_jp"`
+	TitleCN     string  `json:"title_cn"`
+	Brand       string  `json:"brand"`
+	ReleaseDate string  `json:"release_date"`
+	Score       float64 `json:"score"`
+}
+
+type Match struct {
+	Entry      Entry       `json:"entry"`
+	Candidates []Candidate `json:"candidates"`
+	GameID     int64       `json:"game_id"`
+	Confidence float64     `json:"confidence"`
+	Decision   string      `json:"decision"`
+}
+
+type indexedGame struct {
+	candidate Candidate
+	titles    []string
+	bigrams   []map[string]int
+	brand     string
+}
+
+type Matcher struct {
+	games   []indexedGame
+	byTitle map[string][]int
+}
+
+func NewMatcher(games []models.Galgame) *Matcher {
+	m := &Matcher{byTitle: make(map[string][]int)}
+	for _, g := range games {
+		ig := indexedGame{
+			candidate: Candidate{
+				GameID:  g.ID,
+				TitleJP: g.TitleJP,
+			},
+		}
+		if g.TitleCN != nil {
+			ig.candidate.TitleCN = *g.TitleCN
+		}
+		if g.Brand != nil {
+			ig.candidate.Brand = *g.Brand
+			ig.brand = normalize(*g.Brand)
+		}
+		if !g.ReleaseDate.SortKey().IsZero() {
+			ig.candidate.ReleaseDate = g.ReleaseDate.ISO()
+		}
+		for _, title := range []string{ig.candidate.TitleJP, ig.candidate.TitleCN} {
+			n := normalize(title)
+			if n == "" {
+				continue
+			}
+			ig.titles = append(ig.titles, n)
+			ig.bigrams = append(ig.bigrams, bigrams(n))
+			m.byTitle[n] = append(m.byTitle[n], len(m.games))
+		}
+		m.games = append(m.games, ig)
+	}
+	return m
+}
+
+func (m *Matcher) Match(entry Entry) Match {
+	result := Match{Entry: entry, Decision: DecisionNone}
+
+	var titles []string
+	for _, t := range []string{entry.Title, entry.AltTitle} {
+		if n := normalize(t); n != "" {
+			titles = append(titles, n)
+		}
+	}
+	if len(titles) == 0 {
+		return result
+	}
+	brand := normalize(entry.Brand)
+
+	var candidates []Candidate
+	for _, ig := range m.candidatesFor(titles) {
+		titleScore := 0.0
+		for _, t := range titles {
+			tb := bigrams(t)
+			for i, gt := range ig.titles {
+				titleScore = max(titleScore, similarity(t, tb, gt, ig.bigrams[i]))
+			}
+		}
+		if titleScore < minTitleScore {
+			continue
+		}
+
+		score := titleScore + brandAdjustment(brand, ig.brand) + dateAdjustment(entry.ReleaseDate, ig.candidate.ReleaseDate)
+		c := ig.candidate
+		c.Score = min(max(score, 0), 1)
+		candidates = append(candidates, c)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].GameID > candidates[j].GameID
+	})
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	result.Candidates = candidates
+	if len(candidates) == 0 {
+		return result
+	}
+
+	best := candidates[0]
+	result.Confidence = best.Score
+	clearWinner := len(candidates) == 1 || best.Score-candidates[1].Score >= 0.05
+	switch {
+	case best.Score >= AutoMatchThreshold && clearWinner:
+		result.GameID = best.GameID
+		result.Decision = DecisionAuto
+	case best.Score >= ReviewMatchThreshold:
+		result.GameID = best.GameID
+		result.Decision = DecisionReview
+	}
+	return result
+}
+
+// candidatesFor 在存在完全相同的标题时只比较这些游戏, 否则退化为全量模糊比较。
+func (m *Matcher) candidatesFor(titles []string) []indexedGame {
+	var exact []indexedGame
+	seen := make(map[int]struct{})
+	for _, t := range titles {
+		for _, idx := range m.byTitle[t] {
+			if _, ok := seen[idx]; ok {
+				continue
+			}
+			seen[idx] = struct{}{}
+			exact = append(exact, m.games[idx])
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return m.games
+}
+
+func normalize(s string) string {
+	s = norm.NFKC.String(s)
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	result := make(map[string]int, len(runes))
+	if len(runes) == 1 {
+		result[s]++
+		return result
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		result[string(runes[i:i+2])]++
+	}
+	return result
+}
+
+func similarity(a string, aBigrams map[string]int, b string, bBigrams map[string]int) float64 {
+	if a == b {
+		return 1
+	}
+
+	total := 0
+	for _, n := range aBigrams {
+		total += n
+	}
+	for _, n := range bBigrams {
+		total += n
+	}
+	common := 0
+	for gram, n := range aBigrams {
+		common += min(n, bBigrams[gram])
+	}
+	dice := 0.0
+	if total > 0 {
+		dice = 2 * float64(common) / float64(total)
+	}
+
+	// 一方是另一方的前缀或子串时 (常见于副标题差异), 按长度比例给出较高的分数
+	shorter, longer := a, b
+	if len([]rune(shorter)) > len([]rune(longer)) {
+		shorter, longer = longer, shorter
+	}
+	if len([]rune(shorter)) >= 4 && strings.Contains(longer, shorter) {
+		ratio := float64(len([]rune(shorter))) / float64(len([]rune(longer)))
+		dice = max(dice, 0.7+0.25*ratio)
+	}
+	return dice
+}
+
+func brandAdjustment(entryBrand string, gameBrand string) float64 {
+	if entryBrand == "" || gameBrand == "" {
+		return 0
+	}
+	if entryBrand == gameBrand || strings.Contains(entryBrand, gameBrand) || strings.Contains(gameBrand, entryBrand) {
+		return 0.05
+	}
+	return -0.1
+}
+
+// dateAdjustment 比较导入条目和目录中的发售日期。目录中的日期可能只精确到年或月
+// ("2026"、"2026-04"、"2026年春"), 只有两边都精确到日时才比较完整日期。
+func dateAdjustment(entryDate string, gameDate string) float64 {
+	if len(entryDate) >= 10 && len(gameDate) >= 10 && entryDate[:10] == gameDate[:10] {
+		return 0.05
+	}
+	entryYear, okEntry := leadingYear(entryDate)
+	gameYear, okGame := leadingYear(gameDate)
+	if !okEntry || !okGame || entryYear == gameYear {
+		return 0
+	}
+	diff := entryYear - gameYear
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > 1 {
+		return -0.15
+	}
+	return -0.05
+}
+
+// leadingYear 取日期开头的四位年份, "未定" 之类没有年份的日期返回 false。
+func leadingYear(date string) (int, bool) {
+	if len(date) < 4 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0, false
+	}
+	return year, true
+}
@@ -0,0 +1,32 @@
+package importer
+
+import "testing"
+
+func TestDateAdjustment(t *testing.T) {
+	tests := []struct {
+		name      string
+		entryDate string
+		gameDate  string
+		want      float64
+	}{
+		{"same day", "2026-04-10", "2026-04-10", 0.05},
+		{"same year different day", "2026-04-10", "2026-05-01", 0},
+		{"adjacent year", "2026-04-10", "2025-12-31", -0.05},
+		{"distant year", "2026-04-10", "2020-01-01", -0.15},
+		{"catalog year only", "2026-04-10", "2026", 0},
+		{"catalog month only", "2026-04-10", "2026-04", 0},
+		{"catalog season", "2026-04-10", "2026年春", 0},
+		{"catalog season other year", "2026-04-10", "2024年春", -0.15},
+		{"catalog unknown", "2026-04-10", "未定", 0},
+		{"entry year only", "2026", "2026-04-10", 0},
+		{"entry empty", "", "2026-04-10", 0},
+		{"both empty", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dateAdjustment(tt.entryDate, tt.gameDate); got != tt.want {
+				t.Errorf("dateAdjustment(%q, %q) = %v, want %v", tt.entryDate, tt.gameDate, got, tt.want)
+			}
+		})
+	}
+}
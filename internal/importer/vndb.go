@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"galgame-gui/internal/models"
+	"strconv"
+	"strings"
+)
+
+// VNDB 内置标签的ID, 参见 https://vndb.org/d11
+var vndbLabelStatus = map[int]string{
+	1: models.UserStatusPlaying,
+	2: models.UserStatusFinished,
+	3: models.UserStatusOnHold,
+	4: models.UserStatusDropped,
+	5: models.UserStatusWish,
+}
+
+type vndbLabel struct {
+	ID    int    `xml:"id,attr" json:"id"`
+	Label string `xml:"label,attr" json:"label"`
+}
+
+// vndbStatusFromLabels 取第一个能映射的内置标签, 自定义标签被忽略。
+func vndbStatusFromLabels(labels []vndbLabel) string {
+	for _, l := range labels {
+		if status, ok := vndbLabelStatus[l.ID]; ok {
+			return status
+		}
+	}
+	return ""
+}
+
+// vndbVote 把 VNDB 10-100 刻度的投票换算为 0-10 的评分。
+func vndbVote(vote float64) int {
+	return clampRating(vote / 10)
+}
+
+// vndbXMLVote 解析 XML 导出中的投票。旧版导出使用 1.0-10.0 的小数, 新版与 API 相同使用 10-100 的整数;
+// 10 在两种刻度下含义不同, 只能按原始文本区分: 带小数点或小于 10 的值按旧刻度处理。
+func vndbXMLVote(text string) (int, bool) {
+	text = strings.TrimSpace(text)
+	vote, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	if strings.Contains(text, ".") || vote < 10 {
+		return clampRating(vote), true
+	}
+	return vndbVote(vote), true
+}
+
+type vndbXMLExport struct {
+	VNs []struct {
+		ID    string `xml:"id,attr"`
+		Title struct {
+			Text     string `xml:",chardata"`
+			Original string `xml:"original,attr"`
+		} `xml:"title"`
+		Labels []vndbLabel `xml:"label"`
+		Vote   string      `xml:"vote"`
+		Notes  string      `xml:"notes"`
+	} `xml:"vns>vn"`
+}
+
+func parseVNDBXML(data []byte) ([]Entry, error) {
+	var export vndbXMLExport
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	if err := decoder.Decode(&export); err != nil {
+		return nil, fmt.Errorf("解析VNDB XML导出文件失败: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(export.VNs))
+	for _, vn := range export.VNs {
+		entry := Entry{
+			ExternalID: vn.ID,
+			Title:      strings.TrimSpace(vn.Title.Text),
+			AltTitle:   strings.TrimSpace(vn.Title.Original),
+			Status:     vndbStatusFromLabels(vn.Labels),
+			Notes:      strings.TrimSpace(vn.Notes),
+		}
+		if rating, ok := vndbXMLVote(vn.Vote); ok {
+			entry.Rating = rating
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type vndbJSONItem struct {
+	ID string `json:"id"`
+	VN struct {
+		Title      string `json:"title"`
+		AltTitle   string `json:"alttitle"`
+		Released   string `json:"released"`
+		Developers []struct {
+			Name     string `json:"name"`
+			Original string `json:"original"`
+		} `json:"developers"`
+	} `json:"vn"`
+	Labels []vndbLabel `json:"labels"`
+	Vote   *float64    `json:"vote"`
+	Notes  *string     `json:"notes"`
+}
+
+// parseVNDBJSON 同时支持 /ulist API 的响应 ({"results": [...]}) 和裸数组。
+func parseVNDBJSON(data []byte) ([]Entry, error) {
+	var items []vndbJSONItem
+	trimmed := stripBOM(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("解析VNDB JSON导出文件失败: %w", err)
+		}
+	} else {
+		var wrapper struct {
+			Results []vndbJSONItem `json:"results"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapper); err != nil {
+			return nil, fmt.Errorf("解析VNDB JSON导出文件失败: %w", err)
+		}
+		items = wrapper.Results
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		entry := Entry{
+			ExternalID:  item.ID,
+			Title:       strings.TrimSpace(item.VN.Title),
+			AltTitle:    strings.TrimSpace(item.VN.AltTitle),
+			ReleaseDate: item.VN.Released,
+			Status:      vndbStatusFromLabels(item.Labels),
+		}
+		if len(item.VN.Developers) > 0 {
+			entry.Brand = item.VN.Developers[0].Original
+			if entry.Brand == "" {
+				entry.Brand = item.VN.Developers[0].Name
+			}
+		}
+		if item.Vote != nil {
+			entry.Rating = vndbVote(*item.Vote)
+		}
+		if item.Notes != nil {
+			entry.Notes = strings.TrimSpace(*item.Notes)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
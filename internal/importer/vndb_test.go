@@ -0,0 +1,53 @@
+package importer
+
+import "testing"
+
+func TestVNDBVote(t *testing.T) {
+	tests := []struct {
+		vote float64
+		want int
+	}{
+		{10, 1},
+		{55, 6},
+		{85, 9},
+		{100, 10},
+	}
+	for _, tt := range tests {
+		if got := vndbVote(tt.vote); got != tt.want {
+			t.Errorf("vndbVote(%v) = %d, want %d", tt.vote, got, tt.want)
+		}
+	}
+}
+
+func TestVNDBXMLVote(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+		ok   bool
+	}{
+		{"10", 1, true},
+		{" 85 ", 9, true},
+		{"100", 10, true},
+		{"10.0", 10, true},
+		{"8.5", 9, true},
+		{"7", 7, true},
+		{"", 0, false},
+		{"-", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := vndbXMLVote(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("vndbXMLVote(%q) = %d, %v, want %d, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseVNDBJSONVote(t *testing.T) {
+	entries, err := parseVNDBJSON([]byte(`[{"id":"v1","vn":{"title":"A"},"vote":10},{"id":"v2","vn":{"title":"B"},"vote":90}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Rating != 1 || entries[1].Rating != 9 {
+		t.Errorf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,205 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"galgame-gui/internal/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusAlive           = "alive"
+	StatusDead            = "dead"
+	StatusRequiresCode    = "requires_code"
+	StatusUnknownProvider = "unknown_provider"
+	StatusUnreachable     = "unreachable"
+
+	DefaultCheckConcurrency = 4
+	DefaultHostInterval     = 1500 * time.Millisecond
+
+	maxPageSize = 512 << 10
+	userAgent   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+)
+
+// Target 是一条待探测的链接; Code 为从说明文字中提取到的提取码, URL 中未附带时用于判断是否需要提取码。
+type Target struct {
+	GameID int64
+	URL    string
+	Code   string
+}
+
+// Checker 并发探测下载链接, 同一域名的请求之间至少间隔 hostInterval, 避免触发网盘的风控。
+type Checker struct {
+	client       *http.Client
+	concurrency  int
+	hostInterval time.Duration
+
+	mu       sync.Mutex
+	nextSlot map[string]time.Time
+}
+
+func NewChecker(concurrency int, hostInterval time.Duration) *Checker {
+	if concurrency <= 0 {
+		concurrency = DefaultCheckConcurrency
+	}
+	return &Checker{
+		client:       &http.Client{Timeout: 20 * time.Second},
+		concurrency:  concurrency,
+		hostInterval: hostInterval,
+		nextSlot:     make(map[string]time.Time),
+	}
+}
+
+// Check 探测所有目标, onResult 在每个结果产生时调用 (可能来自多个 goroutine)。
+func (c *Checker) Check(ctx context.Context, targets []Target, onResult func(models.LinkCheck)) []models.LinkCheck {
+	results := make([]models.LinkCheck, len(targets))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < c.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.CheckOne(ctx, targets[i])
+				if onResult != nil && !results[i].CheckedAt.IsZero() {
+					onResult(results[i])
+				}
+			}
+		}()
+	}
+
+	for i := range targets {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		checked := results[:0]
+		for _, r := range results {
+			if !r.CheckedAt.IsZero() {
+				checked = append(checked, r)
+			}
+		}
+		results = checked
+	}
+	return results
+}
+
+func (c *Checker) CheckOne(ctx context.Context, target Target) models.LinkCheck {
+	result := models.LinkCheck{GameID: target.GameID, URL: target.URL}
+
+	u, err := url.Parse(target.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		result.Status = StatusUnknownProvider
+		result.Detail = "不是可探测的 http(s) 链接"
+		result.CheckedAt = time.Now()
+		return result
+	}
+	provider := FindProvider(target.URL)
+	if provider == nil {
+		result.Status = StatusUnknownProvider
+		result.Detail = "未知的网盘: " + u.Hostname()
+		result.CheckedAt = time.Now()
+		return result
+	}
+
+	// 检查被取消时不填 CheckedAt, 由 Check 丢弃, 以免把未探测的链接记为无法访问
+	if err := c.waitForHost(ctx, strings.ToLower(u.Hostname())); err != nil {
+		return result
+	}
+
+	status, detail, httpStatus := c.probe(ctx, provider, u, target.Code)
+	if ctx.Err() != nil {
+		return result
+	}
+	result.Status, result.Detail, result.HTTPStatus = status, detail, httpStatus
+	result.CheckedAt = time.Now()
+	return result
+}
+
+func (c *Checker) waitForHost(ctx context.Context, host string) error {
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.nextSlot[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	c.nextSlot[host] = slot.Add(c.hostInterval)
+	c.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Checker) probe(ctx context.Context, provider *Provider, u *url.URL, code string) (string, string, int) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return StatusUnreachable, err.Error(), 0
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return StatusUnreachable, fmt.Sprintf("请求失败: %v", err), 0
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+
+		}
+	}(res.Body)
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return StatusDead, fmt.Sprintf("HTTP %d", res.StatusCode), res.StatusCode
+	case res.StatusCode >= 400:
+		return StatusUnreachable, fmt.Sprintf("HTTP %d", res.StatusCode), res.StatusCode
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxPageSize))
+	if err != nil {
+		return StatusUnreachable, fmt.Sprintf("读取页面失败: %v", err), res.StatusCode
+	}
+	page := string(body)
+	finalURL := res.Request.URL.String()
+
+	for _, marker := range provider.DeadMarkers {
+		if strings.Contains(page, marker) || strings.Contains(finalURL, marker) {
+			return StatusDead, provider.Name + ": " + marker, res.StatusCode
+		}
+	}
+
+	hasCode := code != "" || (provider.CodeParam != "" && u.Query().Get(provider.CodeParam) != "")
+	if !hasCode {
+		for _, marker := range provider.CodeMarkers {
+			if strings.Contains(page, marker) {
+				return StatusRequiresCode, provider.Name + ": 链接未附带提取码", res.StatusCode
+			}
+		}
+	}
+	return StatusAlive, provider.Name, res.StatusCode
+}
@@ -0,0 +1,160 @@
+package links
+
+import (
+	"context"
+	"galgame-gui/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rewriteTransport 把所有请求转发到测试服务器, 保留原始域名以便按网盘识别。
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = t.target.Scheme
+	out.URL.Host = t.target.Host
+	res, err := http.DefaultTransport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	res.Request = req
+	return res, nil
+}
+
+func newTestChecker(t *testing.T, hostInterval time.Duration, handler http.Handler) *Checker {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker(4, hostInterval)
+	c.client = &http.Client{Transport: rewriteTransport{target: target}, Timeout: 5 * time.Second}
+	return c
+}
+
+func testPages() http.Handler {
+	mux := http.NewServeMux()
+	page := func(path string, status int, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	page("/s/alive", http.StatusOK, "<title>百度网盘 文件分享</title>")
+	page("/s/cancelled", http.StatusOK, "<p>啊哦，你来晚了，分享的文件已经被取消了</p>")
+	page("/s/locked", http.StatusOK, "<p>请输入提取码</p>")
+	page("/s/notfound", http.StatusNotFound, "")
+	page("/s/gone", http.StatusGone, "")
+	page("/s/forbidden", http.StatusForbidden, "")
+	page("/s/broken", http.StatusInternalServerError, "")
+	page("/error/404", http.StatusOK, "<p>页面不存在</p>")
+	mux.HandleFunc("/s/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/error/404", http.StatusFound)
+	})
+	return mux
+}
+
+func TestCheckOneClassification(t *testing.T) {
+	c := newTestChecker(t, 0, testPages())
+
+	tests := []struct {
+		name       string
+		target     Target
+		wantStatus string
+		wantHTTP   int
+	}{
+		{"正常页面", Target{URL: "https://pan.baidu.com/s/alive"}, StatusAlive, 200},
+		{"页面含失效标记", Target{URL: "https://pan.baidu.com/s/cancelled"}, StatusDead, 200},
+		{"404", Target{URL: "https://pan.baidu.com/s/notfound"}, StatusDead, 404},
+		{"410", Target{URL: "https://pan.baidu.com/s/gone"}, StatusDead, 410},
+		{"403", Target{URL: "https://pan.baidu.com/s/forbidden"}, StatusUnreachable, 403},
+		{"500", Target{URL: "https://pan.baidu.com/s/broken"}, StatusUnreachable, 500},
+		{"跳转到错误页", Target{URL: "https://pan.baidu.com/s/moved"}, StatusDead, 200},
+		{"需要提取码", Target{URL: "https://pan.baidu.com/s/locked"}, StatusRequiresCode, 200},
+		{"说明中给出提取码", Target{URL: "https://pan.baidu.com/s/locked", Code: "ab12"}, StatusAlive, 200},
+		{"链接中附带提取码", Target{URL: "https://pan.baidu.com/s/locked?pwd=ab12"}, StatusAlive, 200},
+		{"未知网盘", Target{URL: "https://example.com/s/alive"}, StatusUnknownProvider, 0},
+		{"磁力链接", Target{URL: "magnet:?xt=urn:btih:abc"}, StatusUnknownProvider, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.CheckOne(context.Background(), tt.target)
+			if got.Status != tt.wantStatus || got.HTTPStatus != tt.wantHTTP {
+				t.Errorf("CheckOne(%q) = %s/%d (%s), want %s/%d", tt.target.URL, got.Status, got.HTTPStatus, got.Detail, tt.wantStatus, tt.wantHTTP)
+			}
+			if got.CheckedAt.IsZero() {
+				t.Errorf("CheckOne(%q) CheckedAt 未设置", tt.target.URL)
+			}
+		})
+	}
+}
+
+func TestCheckSpacesRequestsPerHost(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	c := newTestChecker(t, interval, testPages())
+
+	same := []Target{
+		{URL: "https://pan.baidu.com/s/alive"},
+		{URL: "https://pan.baidu.com/s/alive"},
+		{URL: "https://pan.baidu.com/s/alive"},
+	}
+	start := time.Now()
+	results := c.Check(context.Background(), same, nil)
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("同一域名的 3 个请求耗时 %v, 至少应为 %v", elapsed, 2*interval)
+	}
+	if len(results) != len(same) {
+		t.Fatalf("got %d results, want %d", len(results), len(same))
+	}
+
+	different := []Target{
+		{URL: "https://pan.quark.cn/s/alive"},
+		{URL: "https://www.lanzoui.com/s/alive"},
+		{URL: "https://www.aliyundrive.com/s/alive"},
+	}
+	start = time.Now()
+	c.Check(context.Background(), different, nil)
+	if elapsed := time.Since(start); elapsed >= interval {
+		t.Errorf("不同域名的请求耗时 %v, 不应互相等待", elapsed)
+	}
+}
+
+func TestCheckStopsOnCancel(t *testing.T) {
+	c := newTestChecker(t, time.Hour, testPages())
+	targets := []Target{
+		{URL: "https://pan.baidu.com/s/alive"},
+		{URL: "https://pan.baidu.com/s/alive"},
+		{URL: "https://pan.baidu.com/s/alive"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var reported atomic.Int32
+	done := make(chan []models.LinkCheck)
+	go func() {
+		done <- c.Check(ctx, targets, func(models.LinkCheck) {
+			reported.Add(1)
+			cancel()
+		})
+	}()
+
+	// 同一域名的后续请求要等待一小时, 只有取消才能让 Check 及时返回
+	var results []models.LinkCheck
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后 Check 没有返回")
+	}
+	if len(results) != 1 || results[0].Status != StatusAlive || reported.Load() != 1 {
+		t.Errorf("got %+v (%d reported), want only the first alive result", results, reported.Load())
+	}
+}
@@ -0,0 +1,12 @@
+package links
+
+// Link 对应 download_link 字段 JSON 数组中的一项, 字段与数据服务保持一致。
+type Link struct {
+	Type     string `json:"type"`
+	Size     string `json:"size"`
+	Platform string `json:"platform"`
+	Language string `json:"language"`
+	Host     string `json:"host"`
+	Status   string `json:"status"`
+	URL      string `json:"url"`
+}
@@ -0,0 +1,211 @@
+package links
+
+import (
+	"encoding/json"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	KindCloudDrive = "cloud_drive"
+	KindMagnet     = "magnet"
+	KindTorrent    = "torrent"
+	KindDirect     = "direct"
+)
+
+// DownloadLink 是归一化后的下载链接: URL 中不再夹带说明文字, 提取码和解压密码单独列出。
+type DownloadLink struct {
+	URL          string `json:"url"`
+	Kind         string `json:"kind"`
+	Provider     string `json:"provider,omitempty"`
+	ProviderName string `json:"provider_name,omitempty"`
+	ShareID      string `json:"share_id,omitempty"`
+	Code         string `json:"code,omitempty"`
+	Password     string `json:"password,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	InfoHash     string `json:"info_hash,omitempty"`
+	Size         string `json:"size,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	Language     string `json:"language,omitempty"`
+	Label        string `json:"label,omitempty"`
+}
+
+var (
+	// 只匹配 ASCII 字符, 这样紧跟在链接后的 "提取码" 等中文说明不会被并入 URL。
+	linkPattern   = regexp.MustCompile(`(?i)(?:https?://|magnet:\?)[A-Za-z0-9\-._~:/?#\[\]@!$&()*+,;=%]+`)
+	passwordLabel = regexp.MustCompile(`(?i)(?:解压密码|解壓密碼|解压码|解壓碼|unzip\s*password)\s*[:：=]?\s*(\S+)`)
+	codeLabel     = regexp.MustCompile(`(?i)(?:提取码|提取碼|访问码|訪問碼|密码|密碼|pwd|passcode|password|code)\s*[:：=]?\s*([A-Za-z0-9]{4,8})\b`)
+	trailingPunct = ".,;:!?)]"
+)
+
+// ParseDownloadField 解析 download_link 字段, 返回归一化后的链接列表。
+// 结构化条目中的 url 也可能夹带提取码等说明, 同样按文本处理。
+func ParseDownloadField(raw string) []DownloadLink {
+	raw = strings.TrimSpace(raw)
+	var entries []Link
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return ParseText(raw)
+	}
+
+	var result []DownloadLink
+	for _, l := range entries {
+		parsed := ParseText(l.URL)
+		for i := range parsed {
+			if l.Size != "" {
+				parsed[i].Size = l.Size
+			}
+			parsed[i].Platform = l.Platform
+			parsed[i].Language = l.Language
+			parsed[i].Label = l.Type
+		}
+		result = append(result, parsed...)
+	}
+	return result
+}
+
+// ParseText 从任意文本中找出所有链接, 每个链接之后直到下一个链接之间的文字用于提取提取码和解压密码。
+func ParseText(text string) []DownloadLink {
+	locs := linkPattern.FindAllStringIndex(text, -1)
+	var result []DownloadLink
+	for i, loc := range locs {
+		rawURL := strings.TrimRight(text[loc[0]:loc[1]], trailingPunct)
+		end := len(text)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		link, ok := ParseLink(rawURL)
+		if !ok {
+			continue
+		}
+		applyNotes(&link, text[loc[1]:end])
+		result = append(result, link)
+	}
+	return result
+}
+
+// ParseLink 识别单个链接的类型和网盘, 并从 URL 本身提取提取码、文件名等信息。
+func ParseLink(rawURL string) (DownloadLink, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return DownloadLink{}, false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "magnet":
+		return parseMagnet(rawURL, u), true
+	case "http", "https":
+	default:
+		return DownloadLink{}, false
+	}
+	if u.Host == "" {
+		return DownloadLink{}, false
+	}
+
+	link := DownloadLink{URL: rawURL}
+	if provider := FindProvider(rawURL); provider != nil {
+		link.Kind = KindCloudDrive
+		link.Provider = provider.Key
+		link.ProviderName = provider.Name
+		link.ShareID = shareID(u)
+		if provider.CodeParam != "" {
+			link.Code = u.Query().Get(provider.CodeParam)
+		}
+		return link, true
+	}
+
+	link.FileName = fileNameFromURL(u)
+	if strings.HasSuffix(strings.ToLower(link.FileName), ".torrent") {
+		link.Kind = KindTorrent
+	} else {
+		link.Kind = KindDirect
+	}
+	return link, true
+}
+
+func parseMagnet(rawURL string, u *url.URL) DownloadLink {
+	link := DownloadLink{URL: rawURL, Kind: KindMagnet}
+	query, _ := url.ParseQuery(u.RawQuery)
+	for _, xt := range query["xt"] {
+		if hash, ok := strings.CutPrefix(strings.ToLower(xt), "urn:btih:"); ok {
+			link.InfoHash = hash
+			break
+		}
+	}
+	link.FileName = query.Get("dn")
+	if size, err := strconv.ParseInt(query.Get("xl"), 10, 64); err == nil && size > 0 {
+		link.Size = formatSize(size)
+	}
+	return link
+}
+
+// shareID 取分享路径中 /s/ 之后的部分, 没有时取最后一段路径。
+func shareID(u *url.URL) string {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, seg := range segments {
+		if seg == "s" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+	if last := segments[len(segments)-1]; last != "" {
+		return last
+	}
+	return u.Query().Get("surl")
+}
+
+func fileNameFromURL(u *url.URL) string {
+	for _, key := range []string{"filename", "file", "name"} {
+		if name := u.Query().Get(key); name != "" {
+			return name
+		}
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || !strings.Contains(name, ".") {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// applyNotes 从链接后的说明文字中提取解压密码和提取码; 先取出解压密码, 避免 "解压密码" 被当成提取码。
+func applyNotes(link *DownloadLink, notes string) {
+	if m := passwordLabel.FindStringSubmatchIndex(notes); m != nil {
+		link.Password = strings.TrimRight(notes[m[2]:m[3]], trailingPunct+"，。；")
+		notes = notes[:m[0]] + notes[m[1]:]
+	}
+	if link.Kind == KindCloudDrive && link.Code == "" {
+		if m := codeLabel.FindStringSubmatch(notes); m != nil {
+			link.Code = m[1]
+		}
+	}
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return strconv.FormatFloat(float64(size)/float64(div), 'f', 1, 64) + " " + string("KMGTPE"[exp]) + "iB"
+}
+
+// ShareText 返回便于粘贴分享的文本: 链接后附上提取码和解压密码 (如果有)。
+func (l DownloadLink) ShareText() string {
+	parts := []string{l.URL}
+	if l.Code != "" {
+		parts = append(parts, "提取码: "+l.Code)
+	}
+	if l.Password != "" {
+		parts = append(parts, "解压密码: "+l.Password)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,129 @@
+package links
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []DownloadLink
+	}{
+		{
+			name: "baidu with code",
+			in:   "链接: https://pan.baidu.com/s/1AbCdEfG 提取码: x1y2",
+			want: []DownloadLink{{URL: "https://pan.baidu.com/s/1AbCdEfG", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1AbCdEfG", Code: "x1y2"}},
+		},
+		{
+			name: "full-width colons and unzip password",
+			in:   "https://pan.baidu.com/s/1AbC 提取码：ab12 解压密码：shirogal.com。",
+			want: []DownloadLink{{URL: "https://pan.baidu.com/s/1AbC", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1AbC", Code: "ab12", Password: "shirogal.com"}},
+		},
+		{
+			name: "unzip password is not taken as the code",
+			in:   "https://pan.baidu.com/s/1a 解压密码: abcd1234",
+			want: []DownloadLink{{URL: "https://pan.baidu.com/s/1a", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1a", Password: "abcd1234"}},
+		},
+		{
+			name: "code in the query string",
+			in:   "https://pan.quark.cn/s/abc123?pwd=Q1w2",
+			want: []DownloadLink{{URL: "https://pan.quark.cn/s/abc123?pwd=Q1w2", Kind: KindCloudDrive, Provider: "quark", ProviderName: "夸克网盘", ShareID: "abc123", Code: "Q1w2"}},
+		},
+		{
+			name: "missing code",
+			in:   "阿里云盘 https://www.aliyundrive.com/s/XyZ789",
+			want: []DownloadLink{{URL: "https://www.aliyundrive.com/s/XyZ789", Kind: KindCloudDrive, Provider: "aliyun", ProviderName: "阿里云盘", ShareID: "XyZ789"}},
+		},
+		{
+			name: "multiple links keep their own codes",
+			in:   "百度: https://pan.baidu.com/s/1aaa 提取码:1111\n蓝奏: https://wwi.lanzoui.com/iAbc 密码:2222\n备用 https://pan.baidu.com/s/1bbb",
+			want: []DownloadLink{
+				{URL: "https://pan.baidu.com/s/1aaa", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1aaa", Code: "1111"},
+				{URL: "https://wwi.lanzoui.com/iAbc", Kind: KindCloudDrive, Provider: "lanzou", ProviderName: "蓝奏云", ShareID: "iAbc", Code: "2222"},
+				{URL: "https://pan.baidu.com/s/1bbb", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1bbb"},
+			},
+		},
+		{
+			name: "trailing punctuation is trimmed",
+			in:   "见 (https://pan.baidu.com/s/1zz)。",
+			want: []DownloadLink{{URL: "https://pan.baidu.com/s/1zz", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1zz"}},
+		},
+		{
+			name: "unknown host is a direct link",
+			in:   "https://files.example.com/game_v1.0.zip 密码: abcd",
+			want: []DownloadLink{{URL: "https://files.example.com/game_v1.0.zip", Kind: KindDirect, FileName: "game_v1.0.zip"}},
+		},
+		{
+			name: "torrent file",
+			in:   "https://example.com/dl/%E6%B8%B8%E6%88%8F.torrent",
+			want: []DownloadLink{{URL: "https://example.com/dl/%E6%B8%B8%E6%88%8F.torrent", Kind: KindTorrent, FileName: "游戏.torrent"}},
+		},
+		{
+			name: "magnet",
+			in:   "magnet:?xt=urn:btih:ABCDEF0123&dn=Game&xl=1048576",
+			want: []DownloadLink{{URL: "magnet:?xt=urn:btih:ABCDEF0123&dn=Game&xl=1048576", Kind: KindMagnet, InfoHash: "abcdef0123", FileName: "Game", Size: "1.0 MiB"}},
+		},
+		{name: "no links", in: "暂无资源 ftp://example.com/a.zip", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseText(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseText(%q)\n got  %+v\n want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDownloadField(t *testing.T) {
+	raw := `[{"type":"百度网盘","size":"2.3GB","platform":"PC","language":"中文","url":"https://pan.baidu.com/s/1x 提取码: abcd"},
+		{"type":"磁力","url":"magnet:?xt=urn:btih:ff&xl=2048"}]`
+	want := []DownloadLink{
+		{URL: "https://pan.baidu.com/s/1x", Kind: KindCloudDrive, Provider: "baidu", ProviderName: "百度网盘", ShareID: "1x", Code: "abcd", Size: "2.3GB", Platform: "PC", Language: "中文", Label: "百度网盘"},
+		{URL: "magnet:?xt=urn:btih:ff&xl=2048", Kind: KindMagnet, InfoHash: "ff", Size: "2.0 KiB", Label: "磁力"},
+	}
+	if got := ParseDownloadField(raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDownloadField()\n got  %+v\n want %+v", got, want)
+	}
+
+	// 不是 JSON 时按文本解析
+	got := ParseDownloadField("  https://pan.quark.cn/s/q1 提取码 zz99  ")
+	if len(got) != 1 || got[0].Provider != "quark" || got[0].Code != "zz99" {
+		t.Errorf("ParseDownloadField(text) = %+v", got)
+	}
+}
+
+func TestFindProvider(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://pan.baidu.com/s/1", "baidu"},
+		{"https://PAN.BAIDU.COM/s/1", "baidu"},
+		{"https://www.123pan.com/s/abc", "123pan"},
+		{"https://www.alipan.com/s/abc", "aliyun"},
+		{"https://wwx.lanzn.com/abc", "lanzou"},
+		{"https://notlanzou.com/abc", ""},
+		{"https://pan.baidu.com.example.com/s/1", ""},
+		{"https://example.com/", ""},
+		{"::not a url", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if p := FindProvider(tt.url); p != nil {
+			got = p.Key
+		}
+		if got != tt.want {
+			t.Errorf("FindProvider(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestShareText(t *testing.T) {
+	link := DownloadLink{URL: "https://pan.baidu.com/s/1", Code: "abcd", Password: "pw"}
+	if got, want := link.ShareText(), "https://pan.baidu.com/s/1 提取码: abcd 解压密码: pw"; got != want {
+		t.Errorf("ShareText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package links
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Policy 决定哪些链接可以交给系统浏览器打开。Deny 优先于 Allow;
+// Allow 为空时放行所有未被拒绝的域名。域名同时匹配其子域名。磁力链接没有域名, 只检查协议。
+type Policy struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+var allowedSchemes = map[string]bool{"http": true, "https": true, "magnet": true}
+
+// Normalize 去掉空白、协议前缀和重复项, 统一为小写域名。
+func (p Policy) Normalize() Policy {
+	return Policy{Allow: normalizeDomains(p.Allow), Deny: normalizeDomains(p.Deny)}
+}
+
+func normalizeDomains(domains []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if i := strings.Index(d, "://"); i >= 0 {
+			d = d[i+3:]
+		}
+		d = strings.TrimPrefix(strings.TrimSuffix(strings.SplitN(d, "/", 2)[0], "."), "*.")
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		result = append(result, d)
+	}
+	return result
+}
+
+// Check 校验链接是否允许打开, 返回解析后的 URL。
+func (p Policy) Check(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("无效的链接: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !allowedSchemes[scheme] {
+		return nil, fmt.Errorf("不允许打开 %q 协议的链接", u.Scheme)
+	}
+	if scheme == "magnet" {
+		return u, nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return nil, fmt.Errorf("链接缺少域名")
+	}
+	for _, d := range p.Deny {
+		if hostMatches(host, d) {
+			return nil, fmt.Errorf("域名 %s 在禁止列表中", host)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return u, nil
+	}
+	for _, d := range p.Allow {
+		if hostMatches(host, d) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("域名 %s 不在允许列表中", host)
+}
@@ -0,0 +1,56 @@
+package links
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicyNormalize(t *testing.T) {
+	got := Policy{
+		Allow: []string{" Pan.Baidu.com ", "https://pan.quark.cn/s/", "*.lanzoui.com", "pan.baidu.com", ""},
+		Deny:  []string{"Example.COM."},
+	}.Normalize()
+	want := Policy{
+		Allow: []string{"pan.baidu.com", "pan.quark.cn", "lanzoui.com"},
+		Deny:  []string{"example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	open := Policy{Deny: []string{"bad.com"}}
+	allowList := Policy{Allow: []string{"baidu.com", "pan.quark.cn"}, Deny: []string{"yun.baidu.com"}}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		url    string
+		ok     bool
+	}{
+		{"允许列表为空时放行", open, "https://pan.baidu.com/s/1a", true},
+		{"禁止列表", open, "https://bad.com/x", false},
+		{"禁止列表匹配子域名", open, "http://dl.bad.com/x", false},
+		{"只匹配完整的域名段", open, "https://notbad.com/x", true},
+		{"磁力链接", allowList, "magnet:?xt=urn:btih:abc", true},
+		{"javascript 协议", open, "javascript:alert(1)", false},
+		{"file 协议", open, "file:///etc/passwd", false},
+		{"缺少域名", open, "https:///path", false},
+		{"大写协议和域名", open, "HTTPS://PAN.BAIDU.COM/s/1a", true},
+		{"允许列表匹配子域名", allowList, "https://pan.baidu.com/s/1a", true},
+		{"不在允许列表", allowList, "https://www.aliyundrive.com/s/1a", false},
+		{"禁止优先于允许", allowList, "https://yun.baidu.com/s/1a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.policy.Check(tt.url)
+			if tt.ok && (err != nil || u == nil) {
+				t.Errorf("Check(%q) error = %v, want allowed", tt.url, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("Check(%q) allowed, want error", tt.url)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package links
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Provider 描述一个已知的网盘, 用于判断分享页面是否失效或需要提取码。
+// 标记文本取自各网盘分享页的提示, 网盘改版后需要同步更新。
+type Provider struct {
+	Key         string
+	Name        string
+	Hosts       []string
+	CodeParam   string
+	DeadMarkers []string
+	CodeMarkers []string
+}
+
+var Providers = []Provider{
+	{
+		Key:         "baidu",
+		Name:        "百度网盘",
+		Hosts:       []string{"pan.baidu.com", "yun.baidu.com"},
+		CodeParam:   "pwd",
+		DeadMarkers: []string{"链接不存在", "分享的文件已经被取消", "此链接分享内容可能因为涉及侵权", "啊哦，你来晚了", "error/404"},
+		CodeMarkers: []string{"请输入提取码", "提取码"},
+	},
+	{
+		Key:         "123pan",
+		Name:        "123云盘",
+		Hosts:       []string{"123pan.com", "123pan.cn", "123684.com", "123865.com", "123912.com"},
+		CodeParam:   "pwd",
+		DeadMarkers: []string{"分享链接已失效", "分享已被取消", "文件已被删除", "该分享已过期"},
+		CodeMarkers: []string{"请输入提取码"},
+	},
+	{
+		Key:         "aliyun",
+		Name:        "阿里云盘",
+		Hosts:       []string{"aliyundrive.com", "alipan.com"},
+		DeadMarkers: []string{"分享已失效", "来晚了"},
+		CodeMarkers: []string{"请输入提取码"},
+	},
+	{
+		Key:         "quark",
+		Name:        "夸克网盘",
+		Hosts:       []string{"pan.quark.cn"},
+		CodeParam:   "pwd",
+		DeadMarkers: []string{"分享地址已失效", "文件已被分享者删除"},
+		CodeMarkers: []string{"请输入提取码"},
+	},
+	{
+		Key:         "lanzou",
+		Name:        "蓝奏云",
+		Hosts:       []string{"lanzou.com", "lanzoui.com", "lanzoux.com", "lanzouw.com", "lanzoum.com", "lanzouo.com", "lanzn.com"},
+		CodeParam:   "pwd",
+		DeadMarkers: []string{"文件取消分享了", "来晚啦", "文件不存在"},
+		CodeMarkers: []string{"输入密码", "请输入密码"},
+	},
+}
+
+func hostMatches(host string, pattern string) bool {
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// FindProvider 按链接的域名查找网盘, 未知域名返回 nil。
+func FindProvider(rawURL string) *Provider {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	for i := range Providers {
+		for _, h := range Providers[i].Hosts {
+			if hostMatches(host, h) {
+				return &Providers[i]
+			}
+		}
+	}
+	return nil
+}
@@ -11,7 +11,7 @@ type Galgame struct {
 	TitleJP      string
 	TitleCN      *string
 	Brand        *string
-	ReleaseDate  time.Time
+	ReleaseDate  ReleaseDate
 	Synopsis     *string
 	CoverURL     *string
 	PreviewURLs  *string
@@ -19,6 +19,13 @@ type Galgame struct {
 	DownloadLink *string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+
+	OverriddenFields []string
+	IsLocal          bool
+
+	CoverBlurhash      string
+	CoverDominantColor string
+	CoverAccentColor   string
 }
 
 func (g *Galgame) UnmarshalJSON(data []byte) error {
@@ -51,24 +58,27 @@ func (g *Galgame) UnmarshalJSON(data []byte) error {
 	const layout = "2006-01-02 15:04:05" // 这是TiDB返回的主要日期格式
 
 	if a.ReleaseDate != nil && *a.ReleaseDate != "" {
-		releaseDate, err := time.Parse(layout, *a.ReleaseDate)
-		if err == nil {
-			g.ReleaseDate = releaseDate
+		releaseDate, err := ParseReleaseDate(*a.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("could not parse release_date %q: %w", *a.ReleaseDate, err)
 		}
+		g.ReleaseDate = releaseDate
 	}
 
 	if a.CreatedAt != nil && *a.CreatedAt != "" {
 		createdAt, err := time.Parse(layout, *a.CreatedAt)
-		if err == nil {
-			g.CreatedAt = createdAt
+		if err != nil {
+			return fmt.Errorf("could not parse created_at %q: %w", *a.CreatedAt, err)
 		}
+		g.CreatedAt = createdAt
 	}
 
 	if a.UpdatedAt != nil && *a.UpdatedAt != "" {
 		updatedAt, err := time.Parse(layout, *a.UpdatedAt)
-		if err == nil {
-			g.UpdatedAt = updatedAt
+		if err != nil {
+			return fmt.Errorf("could not parse updated_at %q: %w", *a.UpdatedAt, err)
 		}
+		g.UpdatedAt = updatedAt
 	}
 
 	g.TitleJP = a.TitleJP
@@ -82,3 +92,45 @@ func (g *Galgame) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// MarshalJSON 按数据服务的字段名和日期格式输出, 与 UnmarshalJSON 对称, 便于保存原始数据后重新解析。
+func (g Galgame) MarshalJSON() ([]byte, error) {
+	const layout = "2006-01-02 15:04:05"
+	formatTime := func(t time.Time) *string {
+		if t.IsZero() {
+			return nil
+		}
+		s := t.Format(layout)
+		return &s
+	}
+
+	formatReleaseDate := func(r ReleaseDate) *string {
+		switch r.Precision {
+		case PrecisionNone:
+			return nil
+		case PrecisionDay:
+			return formatTime(r.Time)
+		}
+		s := r.ISO()
+		return &s
+	}
+
+	return json.Marshal(struct {
+		ID           int64   `json:"id"`
+		TitleJP      string  `json:"title_jp"`
+		TitleCN      *string `json:"title_cn"`
+		Brand        *string `json:"brand"`
+		ReleaseDate  *string `json:"release_date"`
+		CreatedAt    *string `json:"created_at"`
+		UpdatedAt    *string `json:"updated_at"`
+		Synopsis     *string `json:"synopsis"`
+		CoverURL     *string `json:"cover_url"`
+		PreviewURLs  *string `json:"preview_urls"`
+		Tags         *string `json:"tags"`
+		DownloadLink *string `json:"download_link"`
+	}{
+		g.ID, g.TitleJP, g.TitleCN, g.Brand,
+		formatReleaseDate(g.ReleaseDate), formatTime(g.CreatedAt), formatTime(g.UpdatedAt),
+		g.Synopsis, g.CoverURL, g.PreviewURLs, g.Tags, g.DownloadLink,
+	})
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// GameHistoryEntry 是某个游戏在一次写入中发生变化的字段, SyncID 指向造成变化的同步。
+type GameHistoryEntry struct {
+	GameID    int64         `json:"game_id"`
+	SyncID    string        `json:"sync_id"`
+	ChangedAt time.Time     `json:"changed_at"`
+	Created   bool          `json:"created"`
+	Fields    []FieldChange `json:"fields"`
+}
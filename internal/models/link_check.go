@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LinkCheck 是对某个游戏的一条下载链接最近一次探测的结果。
+type LinkCheck struct {
+	GameID     int64     `json:"game_id"`
+	URL        string    `json:"url"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail"`
+	HTTPStatus int       `json:"http_status"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
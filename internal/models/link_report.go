@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LinkReport 是用户提交的失效链接反馈, 在发送到数据服务之前保存在本地发件箱中。
+type LinkReport struct {
+	ID            int64     `json:"id"`
+	GameID        int64     `json:"game_id"`
+	URL           string    `json:"url"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
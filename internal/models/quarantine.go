@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	QuarantineStageDecode   = "decode"
+	QuarantineStageValidate = "validate"
+	QuarantineStageUpsert   = "upsert"
+)
+
+// RowError 是同步中某一行数据被拒绝的原因, Stage 表示在解析、校验还是写入阶段失败。
+type RowError struct {
+	GameID int64  `json:"game_id"`
+	Stage  string `json:"stage"`
+	Error  string `json:"error"`
+}
+
+// QuarantinedGame 是隔离区中的一行。解析阶段 (decode) 被拒绝时 Raw 是数据服务返回的原始 JSON;
+// 校验和写入阶段被拒绝时数据已经解析过, Raw 是重新编码的 Galgame, 不是服务端的原始内容。
+type QuarantinedGame struct {
+	ID            int64     `json:"id"`
+	GameID        int64     `json:"game_id"`
+	SyncID        string    `json:"sync_id"`
+	Stage         string    `json:"stage"`
+	Raw           string    `json:"raw"`
+	Error         string    `json:"error"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 发售日期的精度。未公布日期的游戏只有 "未定" 之类的文字, 精度为 unknown;
+// 数据中完全没有日期时精度为空。
+const (
+	PrecisionNone    = ""
+	PrecisionDay     = "day"
+	PrecisionMonth   = "month"
+	PrecisionSeason  = "season"
+	PrecisionYear    = "year"
+	PrecisionUnknown = "unknown"
+)
+
+// ReleaseDate 是可能只精确到年、月或季节的发售日期。Time 为该时间段的第一天,
+// 季节按 春=3-5月、夏=6-8月、秋=9-11月、冬=12月-次年2月 划分。
+type ReleaseDate struct {
+	Time      time.Time
+	Precision string
+}
+
+var seasons = []struct {
+	name       string
+	aliases    []string
+	startMonth time.Month
+}{
+	{"春", []string{"春", "春季", "spring"}, time.March},
+	{"夏", []string{"夏", "夏季", "summer"}, time.June},
+	{"秋", []string{"秋", "秋季", "autumn", "fall"}, time.September},
+	{"冬", []string{"冬", "冬季", "winter"}, time.December},
+}
+
+var (
+	unknownDatePattern = regexp.MustCompile(`(?i)^(tba|tbd|未定|発売日未定|发售日未定|近日発売|coming soon|unknown)$`)
+	dayPattern         = regexp.MustCompile(`^(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})日?$`)
+	monthPattern       = regexp.MustCompile(`^(\d{4})[-/.年](\d{1,2})月?(?:上旬|中旬|下旬|頃|顷)?$`)
+	yearPattern        = regexp.MustCompile(`^(\d{4})年?(?:内|中|予定)?$`)
+	seasonPattern      = regexp.MustCompile(`(?i)^(\d{4})\s*年?\s*(\S+?)(?:予定)?$`)
+)
+
+var fullLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05"}
+
+// ParseReleaseDate 解析数据服务或用户输入中的发售日期。空字符串返回没有精度的零值。
+func ParseReleaseDate(s string) (ReleaseDate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ReleaseDate{}, nil
+	}
+	if unknownDatePattern.MatchString(s) {
+		return ReleaseDate{Precision: PrecisionUnknown}, nil
+	}
+	for _, layout := range fullLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return ReleaseDate{Time: t, Precision: PrecisionDay}, nil
+		}
+	}
+
+	if m := dayPattern.FindStringSubmatch(s); m != nil {
+		return buildReleaseDate(s, m[1], m[2], m[3], PrecisionDay)
+	}
+	if m := monthPattern.FindStringSubmatch(s); m != nil {
+		return buildReleaseDate(s, m[1], m[2], "1", PrecisionMonth)
+	}
+	if m := yearPattern.FindStringSubmatch(s); m != nil {
+		return buildReleaseDate(s, m[1], "1", "1", PrecisionYear)
+	}
+	if m := seasonPattern.FindStringSubmatch(s); m != nil {
+		name := strings.ToLower(m[2])
+		for _, season := range seasons {
+			for _, alias := range season.aliases {
+				if name == alias {
+					return buildReleaseDate(s, m[1], strconv.Itoa(int(season.startMonth)), "1", PrecisionSeason)
+				}
+			}
+		}
+	}
+	return ReleaseDate{}, fmt.Errorf("无法识别的发售日期 %q", s)
+}
+
+func buildReleaseDate(raw string, year string, month string, day string, precision string) (ReleaseDate, error) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Year() != y || int(t.Month()) != m || t.Day() != d {
+		return ReleaseDate{}, fmt.Errorf("无效的发售日期 %q", raw)
+	}
+	return ReleaseDate{Time: t, Precision: precision}, nil
+}
+
+func (r ReleaseDate) IsZero() bool {
+	return r.Precision == PrecisionNone
+}
+
+// End 返回该时间段的最后一天, 精确到日的日期返回其本身。
+func (r ReleaseDate) End() time.Time {
+	switch r.Precision {
+	case PrecisionMonth:
+		return r.Time.AddDate(0, 1, -1)
+	case PrecisionSeason:
+		return r.Time.AddDate(0, 3, -1)
+	case PrecisionYear:
+		return r.Time.AddDate(1, 0, -1)
+	}
+	return r.Time
+}
+
+// SortKey 是写入 release_date 列的排序键。模糊日期取时间段的最后一天,
+// 这样按日期倒序时 "2026年春" 会排在 2026 年 5 月的确定日期之前, 而不是混在 3 月里。
+// 未定和没有日期的游戏排序键为零值, 排在最后。
+func (r ReleaseDate) SortKey() time.Time {
+	if r.Precision == PrecisionNone || r.Precision == PrecisionUnknown {
+		return time.Time{}
+	}
+	return r.End()
+}
+
+// ReleaseDateFromSortKey 由数据库中的排序键和精度还原发售日期。
+func ReleaseDateFromSortKey(key time.Time, precision string) ReleaseDate {
+	switch precision {
+	case PrecisionNone, PrecisionUnknown:
+		return ReleaseDate{Precision: precision}
+	case PrecisionMonth:
+		return ReleaseDate{Time: time.Date(key.Year(), key.Month(), 1, 0, 0, 0, 0, time.UTC), Precision: precision}
+	case PrecisionSeason:
+		start := time.Date(key.Year(), key.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -2, 0)
+		return ReleaseDate{Time: start, Precision: precision}
+	case PrecisionYear:
+		return ReleaseDate{Time: time.Date(key.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), Precision: precision}
+	}
+	return ReleaseDate{Time: key, Precision: PrecisionDay}
+}
+
+func (r ReleaseDate) seasonName() string {
+	for _, season := range seasons {
+		if r.Time.Month() == season.startMonth {
+			return season.name
+		}
+	}
+	return ""
+}
+
+// String 返回用于界面显示的日期: 2026年4月10日、2026年4月、2026年春、2026年、未定。
+func (r ReleaseDate) String() string {
+	switch r.Precision {
+	case PrecisionDay:
+		return fmt.Sprintf("%d年%d月%d日", r.Time.Year(), r.Time.Month(), r.Time.Day())
+	case PrecisionMonth:
+		return fmt.Sprintf("%d年%d月", r.Time.Year(), r.Time.Month())
+	case PrecisionSeason:
+		return fmt.Sprintf("%d年%s", r.Time.Year(), r.seasonName())
+	case PrecisionYear:
+		return fmt.Sprintf("%d年", r.Time.Year())
+	case PrecisionUnknown:
+		return "未定"
+	}
+	return ""
+}
+
+// ISO 返回机器可读的形式 (2026-04-10、2026-04、2026、未定等), 能被 ParseReleaseDate 重新解析。
+// 季节没有通用的 ISO 写法, 沿用 "2026年春"。
+func (r ReleaseDate) ISO() string {
+	switch r.Precision {
+	case PrecisionDay:
+		return r.Time.Format("2006-01-02")
+	case PrecisionMonth:
+		return r.Time.Format("2006-01")
+	case PrecisionYear:
+		return r.Time.Format("2006")
+	case PrecisionSeason, PrecisionUnknown:
+		return r.String()
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      ReleaseDate
+		display   string
+		iso       string
+		wantError bool
+	}{
+		{in: "", want: ReleaseDate{}},
+		{in: "2026-04-10", want: ReleaseDate{date(2026, 4, 10), PrecisionDay}, display: "2026年4月10日", iso: "2026-04-10"},
+		{in: "2026-04-10 00:00:00", want: ReleaseDate{date(2026, 4, 10), PrecisionDay}, display: "2026年4月10日", iso: "2026-04-10"},
+		{in: "2026年4月10日", want: ReleaseDate{date(2026, 4, 10), PrecisionDay}, display: "2026年4月10日", iso: "2026-04-10"},
+		{in: "2026/4/1", want: ReleaseDate{date(2026, 4, 1), PrecisionDay}, display: "2026年4月1日", iso: "2026-04-01"},
+		{in: "2026-04", want: ReleaseDate{date(2026, 4, 1), PrecisionMonth}, display: "2026年4月", iso: "2026-04"},
+		{in: "2026年4月下旬", want: ReleaseDate{date(2026, 4, 1), PrecisionMonth}, display: "2026年4月", iso: "2026-04"},
+		{in: "2026", want: ReleaseDate{date(2026, 1, 1), PrecisionYear}, display: "2026年", iso: "2026"},
+		{in: "2026年予定", want: ReleaseDate{date(2026, 1, 1), PrecisionYear}, display: "2026年", iso: "2026"},
+		{in: "2026年春", want: ReleaseDate{date(2026, 3, 1), PrecisionSeason}, display: "2026年春", iso: "2026年春"},
+		{in: "2026 winter", want: ReleaseDate{date(2026, 12, 1), PrecisionSeason}, display: "2026年冬", iso: "2026年冬"},
+		{in: "未定", want: ReleaseDate{Precision: PrecisionUnknown}, display: "未定", iso: "未定"},
+		{in: "TBA", want: ReleaseDate{Precision: PrecisionUnknown}, display: "未定", iso: "未定"},
+		{in: "2026-02-30", wantError: true},
+		{in: "2026-13", wantError: true},
+		{in: "someday", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReleaseDate(tt.in)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("ParseReleaseDate(%q) = %+v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReleaseDate(%q) error: %v", tt.in, err)
+			}
+			if !got.Time.Equal(tt.want.Time) || got.Precision != tt.want.Precision {
+				t.Fatalf("ParseReleaseDate(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			if s := got.String(); s != tt.display {
+				t.Errorf("String() = %q, want %q", s, tt.display)
+			}
+			if s := got.ISO(); s != tt.iso {
+				t.Errorf("ISO() = %q, want %q", s, tt.iso)
+			}
+			if tt.iso != "" {
+				again, err := ParseReleaseDate(tt.iso)
+				if err != nil || !again.Time.Equal(got.Time) || again.Precision != got.Precision {
+					t.Errorf("ISO() %q does not round-trip: %+v, %v", tt.iso, again, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseDateSortKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		date ReleaseDate
+		key  time.Time
+	}{
+		{ReleaseDate{date(2026, 4, 10), PrecisionDay}, date(2026, 4, 10)},
+		{ReleaseDate{date(2026, 2, 1), PrecisionMonth}, date(2026, 2, 28)},
+		{ReleaseDate{date(2026, 3, 1), PrecisionSeason}, date(2026, 5, 31)},
+		{ReleaseDate{date(2026, 12, 1), PrecisionSeason}, date(2027, 2, 28)},
+		{ReleaseDate{date(2026, 1, 1), PrecisionYear}, date(2026, 12, 31)},
+		{ReleaseDate{Precision: PrecisionUnknown}, time.Time{}},
+		{ReleaseDate{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		key := tt.date.SortKey()
+		if !key.Equal(tt.key) {
+			t.Errorf("%+v.SortKey() = %v, want %v", tt.date, key, tt.key)
+		}
+		back := ReleaseDateFromSortKey(key, tt.date.Precision)
+		if !back.Time.Equal(tt.date.Time) || back.Precision != tt.date.Precision {
+			t.Errorf("ReleaseDateFromSortKey(%v, %q) = %+v, want %+v", key, tt.date.Precision, back, tt.date)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+const (
+	SyncStatusRunning   = "running"
+	SyncStatusSucceeded = "succeeded"
+	SyncStatusFailed    = "failed"
+)
+
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	ChangeRemoved = "removed"
+)
+
+// FieldChange 是某个字段在一次变更中的旧值和新值。
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SyncChange 是一次同步对某个游戏造成的变更, 新增和删除的游戏没有字段明细。
+type SyncChange struct {
+	RunID  string        `json:"run_id"`
+	GameID int64         `json:"game_id"`
+	Title  string        `json:"title"`
+	Type   string        `json:"type"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// SyncRun 是一次同步的摘要。Added/Updated/Removed 是实际发生变化的游戏数,
+// Deleted/Fetched/Upserted/Rejected 是各阶段处理的行数。
+type SyncRun struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Added      int       `json:"added"`
+	Updated    int       `json:"updated"`
+	Removed    int       `json:"removed"`
+
+	Deleted  int `json:"deleted"`
+	Fetched  int `json:"fetched"`
+	Upserted int `json:"upserted"`
+	Rejected int `json:"rejected"`
+
+	UndoneAt *time.Time `json:"undone_at,omitempty"`
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// GameFieldValues 以列名为键返回游戏中由同步维护的字段, 用于比较两个版本。
+func GameFieldValues(g Galgame) []FieldChange {
+	return []FieldChange{
+		{Field: "title_jp", New: g.TitleJP},
+		{Field: "title_cn", New: derefString(g.TitleCN)},
+		{Field: "brand", New: derefString(g.Brand)},
+		{Field: "release_date", New: g.ReleaseDate.ISO()},
+		{Field: "synopsis", New: derefString(g.Synopsis)},
+		{Field: "cover_url", New: derefString(g.CoverURL)},
+		{Field: "preview_urls", New: derefString(g.PreviewURLs)},
+		{Field: "tags", New: derefString(g.Tags)},
+		{Field: "download_link", New: derefString(g.DownloadLink)},
+	}
+}
+
+// DiffGames 返回两个版本之间值不同的字段。
+func DiffGames(old Galgame, new Galgame) []FieldChange {
+	oldValues, newValues := GameFieldValues(old), GameFieldValues(new)
+	var changes []FieldChange
+	for i := range newValues {
+		if oldValues[i].New != newValues[i].New {
+			changes = append(changes, FieldChange{Field: newValues[i].Field, Old: oldValues[i].New, New: newValues[i].New})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestDiffGames(t *testing.T) {
+	base := Galgame{
+		ID:          1,
+		TitleJP:     "タイトル",
+		TitleCN:     strPtr("标题"),
+		Brand:       strPtr("ブランド"),
+		ReleaseDate: ReleaseDate{date(2026, 4, 1), PrecisionMonth},
+		Tags:        strPtr("纯爱"),
+	}
+	with := func(change func(g *Galgame)) Galgame {
+		g := base
+		change(&g)
+		return g
+	}
+
+	tests := []struct {
+		name string
+		new  Galgame
+		want []FieldChange
+	}{
+		{"identical", base, nil},
+		{
+			"title changed",
+			with(func(g *Galgame) { g.TitleJP = "新タイトル" }),
+			[]FieldChange{{Field: "title_jp", Old: "タイトル", New: "新タイトル"}},
+		},
+		{
+			"field cleared",
+			with(func(g *Galgame) { g.Brand = nil }),
+			[]FieldChange{{Field: "brand", Old: "ブランド", New: ""}},
+		},
+		{
+			"nil and empty are equal",
+			with(func(g *Galgame) { g.Synopsis = strPtr("") }),
+			nil,
+		},
+		{
+			"release date precision refined",
+			with(func(g *Galgame) { g.ReleaseDate = ReleaseDate{date(2026, 4, 24), PrecisionDay} }),
+			[]FieldChange{{Field: "release_date", Old: "2026-04", New: "2026-04-24"}},
+		},
+		{
+			"fields not maintained by sync are ignored",
+			with(func(g *Galgame) { g.ID = 2; g.IsLocal = true; g.OverriddenFields = []string{"title_cn"} }),
+			nil,
+		},
+		{
+			"several fields in column order",
+			with(func(g *Galgame) { g.Tags = strPtr("纯爱,校园"); g.TitleCN = strPtr("新标题") }),
+			[]FieldChange{
+				{Field: "title_cn", Old: "标题", New: "新标题"},
+				{Field: "tags", Old: "纯爱", New: "纯爱,校园"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffGames(base, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffGames() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	UserStatusWish     = "wish"
+	UserStatusPlaying  = "playing"
+	UserStatusFinished = "finished"
+	UserStatusOnHold   = "on_hold"
+	UserStatusDropped  = "dropped"
+)
+
+func IsValidUserStatus(status string) bool {
+	switch status {
+	case "", UserStatusWish, UserStatusPlaying, UserStatusFinished, UserStatusOnHold, UserStatusDropped:
+		return true
+	}
+	return false
+}
+
+// UserGame 是用户对某个游戏的个人数据, 评分为 0-10, 0 表示未评分。
+type UserGame struct {
+	GameID    int64
+	Status    string
+	Rating    int
+	Notes     string
+	UpdatedAt time.Time
+}
@@ -0,0 +1,171 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"galgame-gui/internal/api"
+	"galgame-gui/internal/database"
+	"galgame-gui/internal/models"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultInterval = 5 * time.Minute
+
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = 6 * time.Hour
+
+	// 每次领取的反馈数量和领取的有效期, 有效期内这些反馈不会被其他进程重复发送
+	claimBatch   = 20
+	claimTimeout = 30 * time.Minute
+)
+
+// owner 标识当前进程, 与递增的序号组成每次领取的标记。
+var owner = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d/%d", host, os.Getpid(), time.Now().UnixNano())
+}()
+
+var claimSeq atomic.Int64
+
+// Sender 在后台把发件箱中的失效链接反馈发送到数据服务。
+// 发送前先原子地领取反馈, 多个进程共用一个数据库时同一条反馈不会被重复发送。
+// 网络错误和服务端错误按指数退避重试, 次数不限, 以便离线一段时间后仍能发出;
+// 只有数据服务明确拒绝的反馈 (非超时/限流的 4xx) 才会被丢弃。
+type Sender struct {
+	db       *database.Service
+	client   *api.Client
+	interval time.Duration
+
+	mu   sync.Mutex
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewSender(db *database.Service, client *api.Client, interval time.Duration) *Sender {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Sender{
+		db:       db,
+		client:   client,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func (s *Sender) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.loop(s.stop, s.done)
+}
+
+func (s *Sender) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Wake 让后台立即尝试发送, 用于新反馈入队之后。
+func (s *Sender) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Sender) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.Flush()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Flush 发送所有到期的反馈, 返回成功发送的数量。
+func (s *Sender) Flush() int {
+	sent := 0
+	for {
+		claim := fmt.Sprintf("%s/%d", owner, claimSeq.Add(1))
+		now := time.Now()
+		reports, err := s.db.ClaimLinkReports(claim, now, now.Add(claimTimeout), claimBatch)
+		if err != nil {
+			log.Printf("链接反馈：读取发件箱失败: %v", err)
+			break
+		}
+		if len(reports) == 0 {
+			break
+		}
+		sent += s.send(reports)
+	}
+	if sent > 0 {
+		log.Printf("链接反馈：已发送 %d 条反馈", sent)
+	}
+	return sent
+}
+
+// send 发送已领取的反馈: 成功或被永久拒绝的删除, 其余推迟重试并释放领取。
+func (s *Sender) send(reports []models.LinkReport) int {
+	sent := 0
+	for _, report := range reports {
+		err := s.client.ReportLink(report)
+		if err == nil {
+			sent++
+			if err := s.db.DeleteLinkReport(report.ID); err != nil {
+				log.Printf("链接反馈：删除已发送的反馈 %d 失败: %v", report.ID, err)
+			}
+			continue
+		}
+
+		var statusErr *api.StatusError
+		if errors.As(err, &statusErr) && statusErr.Permanent() {
+			log.Printf("链接反馈：数据服务拒绝了游戏ID %d 的反馈, 已丢弃: %v", report.GameID, err)
+			if err := s.db.DeleteLinkReport(report.ID); err != nil {
+				log.Printf("链接反馈：删除反馈 %d 失败: %v", report.ID, err)
+			}
+			continue
+		}
+
+		next := time.Now().Add(retryDelay(report.Attempts))
+		if err := s.db.MarkLinkReportFailed(report.ID, err.Error(), next); err != nil {
+			log.Printf("链接反馈：更新反馈 %d 失败: %v", report.ID, err)
+		}
+		log.Printf("链接反馈：发送游戏ID %d 的反馈失败, 将于 %s 重试: %v", report.GameID, next.Format("15:04:05"), err)
+	}
+	return sent
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 0; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"encoding/json"
+	"galgame-gui/internal/api"
+	"galgame-gui/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const okResponse = `{"type":"sql_endpoint","data":{"rows":[],"result":{"code":200,"message":"ok"}}}`
+
+// reportServer 按反馈中的链接决定返回的状态码, 并记录收到的请求。
+type reportServer struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	received []string
+	// block 非空时每个请求都会等待它关闭
+	block chan struct{}
+	// arrived 在收到请求时 (等待 block 之前) 发送一个信号
+	arrived chan struct{}
+}
+
+func (f *reportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		URL string `json:"url"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+
+	f.mu.Lock()
+	f.received = append(f.received, payload.URL)
+	status, ok := f.statuses[payload.URL]
+	f.mu.Unlock()
+
+	if f.arrived != nil {
+		f.arrived <- struct{}{}
+	}
+	if f.block != nil {
+		<-f.block
+	}
+	if !ok || status == http.StatusOK {
+		_, _ = w.Write([]byte(okResponse))
+		return
+	}
+	w.WriteHeader(status)
+}
+
+func (f *reportServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.received)
+}
+
+func newTestSender(t *testing.T, server *reportServer) (*database.Service, *Sender) {
+	t.Helper()
+	db, err := database.NewService(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	t.Cleanup(db.Close)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return db, NewSender(db, api.NewClient(ts.URL, "public", "private"), time.Hour)
+}
+
+func pendingByURL(t *testing.T, db *database.Service) map[string]int {
+	t.Helper()
+	reports, err := db.ListLinkReports(time.Time{})
+	if err != nil {
+		t.Fatalf("ListLinkReports: %v", err)
+	}
+	attempts := make(map[string]int)
+	for _, r := range reports {
+		attempts[r.URL] = r.Attempts
+	}
+	return attempts
+}
+
+func TestFlushRetriesOnlyTransientErrors(t *testing.T) {
+	server := &reportServer{statuses: map[string]int{
+		"https://a/ok":          http.StatusOK,
+		"https://a/bad-request": http.StatusBadRequest,
+		"https://a/not-found":   http.StatusNotFound,
+		"https://a/timeout":     http.StatusRequestTimeout,
+		"https://a/rate-limit":  http.StatusTooManyRequests,
+		"https://a/server":      http.StatusInternalServerError,
+		"https://a/unavailable": http.StatusServiceUnavailable,
+	}}
+	db, sender := newTestSender(t, server)
+	for u := range server.statuses {
+		if err := db.QueueLinkReport(1, u, "失效"); err != nil {
+			t.Fatalf("QueueLinkReport: %v", err)
+		}
+	}
+
+	if sent := sender.Flush(); sent != 1 {
+		t.Errorf("Flush() = %d, want 1", sent)
+	}
+	want := map[string]int{
+		"https://a/timeout":     1,
+		"https://a/rate-limit":  1,
+		"https://a/server":      1,
+		"https://a/unavailable": 1,
+	}
+	got := pendingByURL(t, db)
+	if len(got) != len(want) {
+		t.Errorf("pending = %v, want %v", got, want)
+	}
+	for u, attempts := range want {
+		if got[u] != attempts {
+			t.Errorf("%s: attempts = %d, want %d", u, got[u], attempts)
+		}
+	}
+
+	// 失败的反馈推迟到退避时间之后, 立即再次发送不会重复请求
+	before := server.count()
+	if sent := sender.Flush(); sent != 0 || server.count() != before {
+		t.Errorf("second Flush sent %d with %d new requests, want none", sent, server.count()-before)
+	}
+}
+
+func TestFlushBacksOffFailedReports(t *testing.T) {
+	server := &reportServer{statuses: map[string]int{"https://a/server": http.StatusInternalServerError}}
+	db, sender := newTestSender(t, server)
+	if err := db.QueueLinkReport(1, "https://a/server", ""); err != nil {
+		t.Fatalf("QueueLinkReport: %v", err)
+	}
+
+	start := time.Now()
+	sender.Flush()
+	reports, err := db.ListLinkReports(time.Time{})
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("ListLinkReports = %v, %v", reports, err)
+	}
+	delay := reports[0].NextAttemptAt.Sub(start)
+	if delay < baseRetryDelay || delay > baseRetryDelay+time.Minute {
+		t.Errorf("next attempt in %v, want about %v", delay, baseRetryDelay)
+	}
+	if reports[0].LastError == "" {
+		t.Error("LastError was not recorded")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{3, 4 * time.Minute},
+		{9, 30 * time.Second << 9},
+		{10, maxRetryDelay},
+		{1000, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestFlushDoesNotResendClaimedReports(t *testing.T) {
+	server := &reportServer{block: make(chan struct{}), arrived: make(chan struct{}, 1)}
+	db, first := newTestSender(t, server)
+	// 第二个 Sender 模拟另一个 (--multi-instance) 进程, 共用同一个数据库
+	second := NewSender(db, first.client, time.Hour)
+	if err := db.QueueLinkReport(1, "https://a/ok", ""); err != nil {
+		t.Fatalf("QueueLinkReport: %v", err)
+	}
+
+	done := make(chan int)
+	go func() { done <- first.Flush() }()
+	<-server.arrived
+
+	// 第一个进程正在发送时, 再次提交同一反馈也不会让它被另一个进程领取
+	if err := db.QueueLinkReport(1, "https://a/ok", "补充说明"); err != nil {
+		t.Fatalf("QueueLinkReport: %v", err)
+	}
+	if sent := second.Flush(); sent != 0 {
+		t.Errorf("second sender sent %d reports already claimed by the first", sent)
+	}
+	close(server.block)
+
+	if sent := <-done; sent != 1 {
+		t.Errorf("first sender sent %d, want 1", sent)
+	}
+	if n := server.count(); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
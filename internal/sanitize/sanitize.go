@@ -0,0 +1,292 @@
+// Package sanitize 在数据写入本地数据库之前清理文本并校验链接。
+// 文本只去掉 HTML 标签和控制字符, 引号和尖括号原样保存, 由前端在渲染时转义;
+// 链接则必须在这里拒绝 javascript:、data: 等协议, 转义无法让这类链接变得安全。
+package sanitize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"galgame-gui/internal/models"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTitleLength        = 500
+	MaxBrandLength        = 200
+	MaxSynopsisLength     = 20000
+	MaxTagsLength         = 4000
+	MaxURLLength          = 2048
+	MaxPreviewURLs        = 100
+	MaxDownloadLinkLength = 50000
+)
+
+// FieldError 描述一个字段未通过校验的原因。
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// Errors 汇总一行数据中所有未通过校验的字段。
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Reason
+	}
+	return "数据校验失败: " + strings.Join(parts, "; ")
+}
+
+var (
+	// 只把看起来像 HTML 标签、注释或声明的部分当作标签删除
+	// "<体験版>" 之类不像标签的尖括号会保留
+	tagPattern = regexp.MustCompile(`(?s)<[a-zA-Z/!?][^>]*>`)
+
+	// Markdown 链接和图片的目标: [x](target)、![x](target) 以及引用式链接 [x]: target
+	markdownLinkPattern = regexp.MustCompile(`\]\(\s*([^)\s]*)`)
+	markdownRefPattern  = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*(\S+)`)
+	unsafeSchemes       = []string{"javascript:", "vbscript:", "data:", "file:"}
+)
+
+// Text 去掉 HTML 标签和控制字符 (保留换行和制表符, 去掉会破坏 JS 字符串的 U+2028/U+2029)。
+func Text(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == '\u2028' || r == '\u2029' {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// Tags 在 Text 的基础上清理逗号分隔列表中的空项。
+func Tags(s string) string {
+	s = Text(s)
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return strings.Join(tags, ",")
+}
+
+// URL 校验单个图片/页面链接: 只允许 http(s), 且不能含有会破坏 HTML 属性的字符。
+func URL(s string) (string, error) {
+	return checkURL(strings.TrimSpace(s), "http", "https")
+}
+
+func checkURL(s string, schemes ...string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if len(s) > MaxURLLength {
+		return "", fmt.Errorf("链接长度超过 %d", MaxURLLength)
+	}
+	if strings.ContainsAny(s, "\"'`<>\\") || strings.IndexFunc(s, unicode.IsSpace) >= 0 || strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("链接包含非法字符")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("无法解析链接: %v", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	for _, allowed := range schemes {
+		if scheme == allowed {
+			if scheme != "magnet" && u.Host == "" {
+				return "", fmt.Errorf("链接缺少域名")
+			}
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("不允许的链接协议 %q", u.Scheme)
+}
+
+// URLList 逐个校验逗号分隔的链接列表。
+func URLList(s string) (string, error) {
+	var urls []string
+	for _, part := range strings.Split(s, ",") {
+		u, err := URL(part)
+		if err != nil {
+			return "", err
+		}
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) > MaxPreviewURLs {
+		return "", fmt.Errorf("链接数量超过 %d", MaxPreviewURLs)
+	}
+	return strings.Join(urls, ","), nil
+}
+
+// DownloadLink 清理 download_link 字段。JSON 数组中的 url 只允许 http(s) 和磁力链接
+// (允许在链接后附带提取码等说明文字), 其余字符串字段按普通文本清理; 不是 JSON 时整体按文本清理。
+func DownloadLink(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > MaxDownloadLinkLength {
+		return "", fmt.Errorf("长度超过 %d", MaxDownloadLinkLength)
+	}
+	if s == "" || (s[0] != '[' && s[0] != '{') {
+		return Text(s), nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var entries []map[string]interface{}
+	if err := decoder.Decode(&entries); err != nil {
+		return "", fmt.Errorf("不是有效的 JSON 数组: %v", err)
+	}
+	for i, entry := range entries {
+		for key, value := range entry {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			str = Text(str)
+			if key == "url" {
+				if err := checkDownloadURL(str); err != nil {
+					return "", fmt.Errorf("第 %d 项: %v", i+1, err)
+				}
+			}
+			entry[key] = str
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(entries); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func checkDownloadURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	// 链接后可能跟着 "提取码: xxxx" 之类的说明, 只校验第一个空白之前的部分
+	link := s
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		link = s[:i]
+	}
+	if strings.ContainsAny(s, "\"'`") {
+		return fmt.Errorf("链接包含非法字符")
+	}
+	_, err := checkURL(link, "http", "https", "magnet")
+	return err
+}
+
+// Field 按列名清理单个字段的值, 供字段覆盖和本地游戏等用户输入使用。
+func Field(field string, value string) (string, error) {
+	switch field {
+	case "title_jp", "title_cn":
+		return limited(Text(value), MaxTitleLength)
+	case "brand":
+		return limited(Text(value), MaxBrandLength)
+	case "synopsis":
+		return Synopsis(value)
+	case "tags":
+		return limited(Tags(value), MaxTagsLength)
+	case "cover_url":
+		return URL(value)
+	case "preview_urls":
+		return URLList(value)
+	case "download_link":
+		return DownloadLink(value)
+	}
+	return value, nil
+}
+
+// Synopsis 清理简介。简介会经过 Markdown 渲染, 因此除了 Text 的处理之外,
+// 还拒绝指向 javascript:、data: 等协议的链接和图片。
+func Synopsis(s string) (string, error) {
+	s = Text(s)
+	var targets []string
+	for _, m := range markdownLinkPattern.FindAllStringSubmatch(s, -1) {
+		targets = append(targets, m[1])
+	}
+	for _, m := range markdownRefPattern.FindAllStringSubmatch(s, -1) {
+		targets = append(targets, m[1])
+	}
+	for _, target := range targets {
+		if scheme, ok := unsafeScheme(target); ok {
+			return "", fmt.Errorf("简介中包含不允许的链接协议 %q", scheme)
+		}
+	}
+	return limited(s, MaxSynopsisLength)
+}
+
+// unsafeScheme 判断链接目标是否使用了会执行脚本或内嵌内容的协议。浏览器在解析协议前会解码 HTML 实体,
+// 并忽略其中的空白和控制字符, 因此先做同样的处理再比较。
+func unsafeScheme(target string) (string, bool) {
+	target = html.UnescapeString(target)
+	target = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, target)
+	for _, scheme := range unsafeSchemes {
+		if strings.HasPrefix(target, scheme) {
+			return strings.TrimSuffix(scheme, ":"), true
+		}
+	}
+	return "", false
+}
+
+func limited(s string, max int) (string, error) {
+	if utf8.RuneCountInString(s) > max {
+		return "", fmt.Errorf("长度超过 %d 个字符", max)
+	}
+	return s, nil
+}
+
+// Game 清理一条游戏数据的所有文本和链接字段, 任一字段不合格时返回 Errors。
+func Game(game models.Galgame) (models.Galgame, error) {
+	var errs Errors
+	apply := func(field string, value string) string {
+		cleaned, err := Field(field, value)
+		if err != nil {
+			errs = append(errs, FieldError{Field: field, Reason: err.Error()})
+			return value
+		}
+		return cleaned
+	}
+	applyPtr := func(field string, value *string) *string {
+		if value == nil {
+			return nil
+		}
+		cleaned := apply(field, *value)
+		return &cleaned
+	}
+
+	game.TitleJP = apply("title_jp", game.TitleJP)
+	if game.TitleJP == "" {
+		errs = append(errs, FieldError{Field: "title_jp", Reason: "不能为空"})
+	}
+	game.TitleCN = applyPtr("title_cn", game.TitleCN)
+	game.Brand = applyPtr("brand", game.Brand)
+	game.Synopsis = applyPtr("synopsis", game.Synopsis)
+	game.CoverURL = applyPtr("cover_url", game.CoverURL)
+	game.PreviewURLs = applyPtr("preview_urls", game.PreviewURLs)
+	game.Tags = applyPtr("tags", game.Tags)
+	game.DownloadLink = applyPtr("download_link", game.DownloadLink)
+
+	if len(errs) > 0 {
+		return game, errs
+	}
+	return game, nil
+}
@@ -0,0 +1,83 @@
+package sanitize
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"galgame-gui/internal/models"
+)
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		field     string
+		in        string
+		want      string
+		wantError bool
+	}{
+		{field: "title_jp", in: "  普通のタイトル  ", want: "普通のタイトル"},
+		{field: "title_jp", in: "<体験版>", want: "<体験版>"},
+		{field: "title_jp", in: "<b>太字</b>", want: "太字"},
+		{field: "title_jp", in: `x" onerror="alert(1)`, want: `x" onerror="alert(1)`},
+		{field: "title_cn", in: "it's `code`", want: "it's `code`"},
+		{field: "synopsis", in: "> 引用\n\"台词\"", want: "> 引用\n\"台词\""},
+		{field: "brand", in: "a\x00b c", want: "abc"},
+		{field: "title_jp", in: strings.Repeat("あ", MaxTitleLength+1), wantError: true},
+		{field: "tags", in: `a, "b" ,,c'`, want: `a,"b",c'`},
+		{field: "synopsis", in: "第一行\n[官网](https://example.com)", want: "第一行\n[官网](https://example.com)"},
+		{field: "synopsis", in: "[a](javascript:alert(1))", wantError: true},
+		{field: "synopsis", in: "[a]( JavaScript:alert(1))", wantError: true},
+		{field: "synopsis", in: "[a](java&#115;cript:alert(1))", wantError: true},
+		{field: "synopsis", in: "![x](data:text/html;base64,PHNjcmlwdD4=)", wantError: true},
+		{field: "synopsis", in: "[a]: vbscript:msgbox", wantError: true},
+		{field: "synopsis", in: "说明: javascript: 不是链接", want: "说明: javascript: 不是链接"},
+		{field: "cover_url", in: "https://example.com/a.jpg", want: "https://example.com/a.jpg"},
+		{field: "cover_url", in: "javascript:alert(1)", wantError: true},
+		{field: "cover_url", in: `https://example.com/a.jpg" onerror="x`, wantError: true},
+		{field: "cover_url", in: "/relative.jpg", wantError: true},
+		{field: "preview_urls", in: "https://a.com/1.jpg, https://a.com/2.jpg", want: "https://a.com/1.jpg,https://a.com/2.jpg"},
+		{field: "preview_urls", in: "https://a.com/1.jpg,ftp://a.com/2.jpg", wantError: true},
+		{field: "download_link", in: "网盘 https://pan.example.com/s/1", want: "网盘 https://pan.example.com/s/1"},
+		{field: "download_link", in: `[{"url":"magnet:?xt=urn:btih:abc","name":"<b>种子</b>"}]`, want: `[{"name":"种子","url":"magnet:?xt=urn:btih:abc"}]`},
+		{field: "download_link", in: `[{"url":"https://pan.example.com/s/1 提取码: abcd"}]`, want: `[{"url":"https://pan.example.com/s/1 提取码: abcd"}]`},
+		{field: "download_link", in: `[{"url":"javascript:alert(1)"}]`, wantError: true},
+		{field: "download_link", in: `[{"url":`, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+"/"+tt.in, func(t *testing.T) {
+			got, err := Field(tt.field, tt.in)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("Field(%q, %q) = %q, want error", tt.field, tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Field(%q, %q) error: %v", tt.field, tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Field(%q, %q) = %q, want %q", tt.field, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGame(t *testing.T) {
+	bad := "[a](javascript:alert(1))"
+	cover := "https://example.com/a.jpg"
+	game, err := Game(models.Galgame{ID: 1, TitleJP: ` "タイトル" `, Synopsis: &bad, CoverURL: &cover})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "synopsis" {
+		t.Fatalf("Game() error = %v, want one synopsis error", err)
+	}
+	if game.TitleJP != `"タイトル"` {
+		t.Errorf("TitleJP = %q", game.TitleJP)
+	}
+
+	if _, err := Game(models.Galgame{ID: 2, TitleJP: "<>"}); err != nil {
+		t.Errorf("Game() with bracket-only title: %v", err)
+	}
+	if _, err := Game(models.Galgame{ID: 3, TitleJP: "<b></b>"}); err == nil {
+		t.Error("Game() with empty title should fail")
+	}
+}
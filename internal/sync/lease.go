@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"galgame-gui/internal/database"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	leaseName = "sync"
+	// LeaseTTL 是同步锁的有效期, 同步期间每隔 LeaseTTL/3 续期一次
+	LeaseTTL = 2 * time.Minute
+)
+
+// ErrLocked 表示另一个进程正在同步 (或撤销同步)。
+var ErrLocked = errors.New("另一个进程正在同步数据")
+
+// ErrLeaseLost 表示持有期间同步锁过期并被其他进程接手, 操作已经中止。
+var ErrLeaseLost = errors.New("同步锁已被其他进程接手, 操作已中止")
+
+// renewInterval 是持有同步锁期间续期的间隔
+var renewInterval = LeaseTTL / 3
+
+// owner 标识当前进程, 写入同步锁以便排查是谁持有锁。
+var owner = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), NewSyncID())
+}()
+
+// WithLease 持有数据库级的同步锁执行 fn, 期间定期续期, 结束后释放。同一个数据库只会有一个进程
+// 在同步、撤销、恢复备份或重试隔离数据, 无论它们是几个 ShiroGal 窗口还是其他工具。
+// 续期时发现锁已被其他进程接手会取消 ctx, fn 应在每个写入阶段之前检查 ctx 并尽快返回。
+func WithLease(db *database.Service, fn func(ctx context.Context) error) error {
+	ok, err := db.AcquireLease(leaseName, owner, LeaseTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLocked
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(renewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ok, err := db.AcquireLease(leaseName, owner, LeaseTTL)
+				if err != nil {
+					// 数据库暂时繁忙之类的错误, 下次再试; 锁在 LeaseTTL 内仍然有效
+					log.Printf("数据同步：续期同步锁失败: %v", err)
+					continue
+				}
+				if !ok {
+					log.Println("数据同步：同步锁已被其他进程接手, 中止当前操作")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(ctx)
+	lost := ctx.Err() != nil
+	cancel()
+	<-done
+	if err := db.ReleaseLease(leaseName, owner); err != nil {
+		log.Printf("数据同步：%v", err)
+	}
+	if lost && (err == nil || errors.Is(err, context.Canceled)) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+// LeaseHolder 返回当前持有同步锁的进程, 没有进程在同步时返回 nil。
+func LeaseHolder(db *database.Service) (*database.SyncLease, error) {
+	return db.GetLease(leaseName)
+}
+
+// RecoverInterrupted 把上次退出前未完成的同步标记为失败。其他进程正在同步时不做任何事,
+// 以免把它们的同步误判为中断。
+func RecoverInterrupted(db *database.Service) (int, error) {
+	n := 0
+	err := WithLease(db, func(ctx context.Context) error {
+		var err error
+		n, err = db.AbandonSyncRuns("同步在完成前被中断")
+		return err
+	})
+	if errors.Is(err, ErrLocked) {
+		return 0, nil
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"galgame-gui/internal/models"
+)
+
+func TestWithLeaseExcludesOtherOwners(t *testing.T) {
+	db, _, _ := newTestEnv(t)
+
+	if ok, err := db.AcquireLease(leaseName, "other-process", LeaseTTL); err != nil || !ok {
+		t.Fatalf("AcquireLease = %v, %v", ok, err)
+	}
+	called := false
+	err := WithLease(db, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrLocked) || called {
+		t.Fatalf("WithLease = %v (called=%v), want ErrLocked without calling fn", err, called)
+	}
+
+	if err := db.ReleaseLease(leaseName, "other-process"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithLease(db, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("WithLease after release: %v", err)
+	}
+	if holder, err := LeaseHolder(db); err != nil || holder != nil {
+		t.Fatalf("LeaseHolder = %+v, %v; want the lease released", holder, err)
+	}
+}
+
+func TestWithLeaseCancelsWhenLeaseIsLost(t *testing.T) {
+	db, _, _ := newTestEnv(t)
+
+	previous := renewInterval
+	renewInterval = 20 * time.Millisecond
+	defer func() { renewInterval = previous }()
+
+	err := WithLease(db, func(ctx context.Context) error {
+		// 模拟租约过期后被另一个进程接手
+		if err := db.ReleaseLease(leaseName, owner); err != nil {
+			return err
+		}
+		if ok, err := db.AcquireLease(leaseName, "other-process", LeaseTTL); err != nil || !ok {
+			t.Errorf("AcquireLease = %v, %v", ok, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("ctx was not cancelled after the lease was lost")
+		}
+	})
+	if !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("WithLease = %v, want ErrLeaseLost", err)
+	}
+	if holder, err := LeaseHolder(db); err != nil || holder == nil || holder.Owner != "other-process" {
+		t.Fatalf("LeaseHolder = %+v, %v; the other process must keep its lease", holder, err)
+	}
+}
+
+func TestRunAbandonsStaleRuns(t *testing.T) {
+	db, client, fake := newTestEnv(t)
+
+	stale := models.SyncRun{ID: "stale", StartedAt: time.Now().Add(-time.Hour), Status: models.SyncStatusRunning}
+	if err := db.StartSyncRun(stale); err != nil {
+		t.Fatal(err)
+	}
+	// 另一个进程持有锁时, 启动时的清理不能把它的同步当成中断
+	if ok, err := db.AcquireLease(leaseName, "other-process", LeaseTTL); err != nil || !ok {
+		t.Fatalf("AcquireLease = %v, %v", ok, err)
+	}
+	if n, err := RecoverInterrupted(db); err != nil || n != 0 {
+		t.Fatalf("RecoverInterrupted = %d, %v; want 0 while another process holds the lease", n, err)
+	}
+	if err := db.ReleaseLease(leaseName, "other-process"); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.set([]int64{})
+	mustRun(t, db, client)
+
+	runs, err := db.ListSyncRuns(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range runs {
+		if run.Status == models.SyncStatusRunning {
+			t.Errorf("run %s is still marked as running", run.ID)
+		}
+		if run.ID == stale.ID && run.Status != models.SyncStatusFailed {
+			t.Errorf("stale run status = %s, want failed", run.Status)
+		}
+	}
+}
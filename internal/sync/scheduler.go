@@ -0,0 +1,218 @@
+package sync
+
+import (
+	"fmt"
+	"galgame-gui/internal/database"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInterval = 6 * time.Hour
+	MinInterval     = 15 * time.Minute
+
+	intervalSettingKey    = "sync_interval_minutes"
+	lastSuccessSettingKey = "sync_last_success"
+
+	// 上一次同步仍在进行时, 隔一段时间再检查
+	busyRetryDelay = time.Minute
+	baseRetryDelay = time.Minute
+	maxRetryDelay  = 2 * time.Hour
+	jitterFraction = 0.1
+)
+
+// RunFunc 执行一次同步。上一次同步尚未结束、这次被跳过时返回 false。
+type RunFunc func() (bool, error)
+
+// Scheduler 按间隔在后台定期同步。间隔加入 ±10% 的随机抖动, 避免大量客户端同时请求数据服务;
+// 失败后按指数退避重试, 成功后恢复正常间隔。间隔为 0 时不做定期同步。
+type Scheduler struct {
+	db  *database.Service
+	run RunFunc
+
+	mu       sync.Mutex
+	interval time.Duration
+	reset    chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewScheduler(db *database.Service, interval time.Duration, run RunFunc) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		run:      run,
+		interval: interval,
+		reset:    make(chan struct{}, 1),
+	}
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.loop(s.stop, s.done)
+}
+
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (s *Scheduler) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// SetInterval 修改同步间隔并按新间隔重新计算下一次同步的时间。
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+
+	select {
+	case s.reset <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	failures := 0
+	timer := time.NewTimer(s.nextWait())
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.reset:
+			failures = 0
+			resetTimer(timer, s.nextWait())
+			continue
+		case <-timer.C:
+		}
+
+		if s.Interval() <= 0 {
+			timer.Reset(s.nextWait())
+			continue
+		}
+
+		ran, err := s.run()
+		var wait time.Duration
+		switch {
+		case !ran:
+			wait = busyRetryDelay
+			if next := s.nextWait(); next > wait {
+				wait = next
+			}
+		case err != nil:
+			failures++
+			wait = withJitter(retryDelay(failures))
+			log.Printf("定时同步：第 %d 次失败, 将于 %s 重试", failures, time.Now().Add(wait).Format("15:04:05"))
+		default:
+			failures = 0
+			wait = s.nextWait()
+		}
+		timer.Reset(wait)
+	}
+}
+
+// nextWait 根据上一次成功同步的时间计算距离下一次定期同步的等待时间。
+// 应用关闭期间错过的同步会在启动后立即补上。
+func (s *Scheduler) nextWait() time.Duration {
+	interval := s.Interval()
+	if interval <= 0 {
+		// 定期同步已关闭, 定时器只用于等待间隔被重新设置
+		return 24 * time.Hour
+	}
+	wait := withJitter(interval)
+	if last, ok, err := LastSuccess(s.db); err == nil && ok {
+		wait -= time.Since(last)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+func retryDelay(failures int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < failures && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func withJitter(d time.Duration) time.Duration {
+	spread := int64(float64(d) * jitterFraction)
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
+
+// LoadInterval 读取设置中的同步间隔, 没有设置时使用 DefaultInterval。
+func LoadInterval(db *database.Service) (time.Duration, error) {
+	value, ok, err := db.GetSetting(intervalSettingKey)
+	if err != nil || !ok {
+		return DefaultInterval, err
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil {
+		return DefaultInterval, fmt.Errorf("同步间隔设置无效 %q: %w", value, err)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// SaveInterval 保存同步间隔, 0 表示关闭定期同步, 其余值不能小于 MinInterval。
+func SaveInterval(db *database.Service, interval time.Duration) error {
+	if interval < 0 || (interval > 0 && interval < MinInterval) {
+		return fmt.Errorf("同步间隔不能小于 %d 分钟", int(MinInterval.Minutes()))
+	}
+	return db.SetSetting(intervalSettingKey, strconv.Itoa(int(interval.Minutes())))
+}
+
+// LastSuccess 返回上一次成功同步的完成时间。
+func LastSuccess(db *database.Service) (time.Time, bool, error) {
+	value, ok, err := db.GetSetting(lastSuccessSettingKey)
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+	last, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("上次同步时间无效 %q: %w", value, err)
+	}
+	return last, true, nil
+}
+
+func saveLastSuccess(db *database.Service, t time.Time) error {
+	return db.SetSetting(lastSuccessSettingKey, t.UTC().Format(time.RFC3339))
+}
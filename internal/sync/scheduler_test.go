@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, baseRetryDelay},
+		{1, baseRetryDelay},
+		{2, 2 * baseRetryDelay},
+		{3, 4 * baseRetryDelay},
+		{7, 64 * baseRetryDelay},
+		{8, maxRetryDelay},
+		{1000, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.failures); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	tests := []struct {
+		d          time.Duration
+		minD, maxD time.Duration
+	}{
+		{0, 0, 0},
+		{5 * time.Nanosecond, 5 * time.Nanosecond, 5 * time.Nanosecond},
+		{time.Hour, 54 * time.Minute, 66 * time.Minute},
+		{DefaultInterval, DefaultInterval - 36*time.Minute, DefaultInterval + 36*time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			got := withJitter(tt.d)
+			if got < tt.minD || got > tt.maxD {
+				t.Fatalf("withJitter(%v) = %v, want within [%v, %v]", tt.d, got, tt.minD, tt.maxD)
+			}
+		}
+	}
+}
+
+func TestSaveInterval(t *testing.T) {
+	db, _, _ := newTestEnv(t)
+	if got, err := LoadInterval(db); err != nil || got != DefaultInterval {
+		t.Fatalf("LoadInterval on a new database = %v, %v, want %v", got, err, DefaultInterval)
+	}
+
+	tests := []struct {
+		interval time.Duration
+		wantErr  bool
+	}{
+		{0, false},
+		{MinInterval, false},
+		{3 * time.Hour, false},
+		{MinInterval - time.Minute, true},
+		{-time.Hour, true},
+	}
+	for _, tt := range tests {
+		err := SaveInterval(db, tt.interval)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SaveInterval(%v) error = %v, wantErr %v", tt.interval, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got, err := LoadInterval(db); err != nil || got != tt.interval {
+			t.Errorf("LoadInterval after saving %v = %v, %v", tt.interval, got, err)
+		}
+	}
+}
@@ -1,22 +1,130 @@
 package sync
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"galgame-gui/internal/api"
 	"galgame-gui/internal/database"
+	"galgame-gui/internal/models"
+	"log"
 	"time"
 )
 
-func Run(db *database.Service, apiClient *api.Client) error {
+// SnapshotRetention 是保留快照 (可以撤销) 的最近同步次数。
+const SnapshotRetention = 10
+
+// Report 汇总一次同步的结果, RowErrors 列出被拒绝并放入隔离区的行。
+type Report struct {
+	SyncID    string            `json:"sync_id"`
+	Deleted   int               `json:"deleted"`
+	Fetched   int               `json:"fetched"`
+	Upserted  int               `json:"upserted"`
+	RowErrors []models.RowError `json:"row_errors"`
+}
+
+// NewSyncID 生成一次同步 (或一次隔离重试) 的标识, 用于关联隔离区中的行。
+func NewSyncID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// Run 执行一次同步。无论成功与否都会在 sync_runs 中留下记录, 失败前已经发生的变更也会被记录。
+// 另一个进程正在同步时不做任何事, 返回 ErrLocked。
+func Run(db *database.Service, apiClient *api.Client) (Report, error) {
+	report := Report{SyncID: NewSyncID(), RowErrors: []models.RowError{}}
+	err := WithLease(db, func(ctx context.Context) error {
+		return runLocked(ctx, db, apiClient, &report)
+	})
+	return report, err
+}
+
+func runLocked(ctx context.Context, db *database.Service, apiClient *api.Client, report *Report) error {
+	// 持有同步锁说明没有其他进程在同步, 仍标记为进行中的记录一定是崩溃留下的
+	if n, err := db.AbandonSyncRuns("同步在完成前被中断"); err != nil {
+		log.Printf("数据同步：%v", err)
+	} else if n > 0 {
+		log.Printf("数据同步：%d 次未完成的同步已标记为失败", n)
+	}
+
+	run := models.SyncRun{ID: report.SyncID, StartedAt: time.Now(), Status: models.SyncStatusRunning}
+	if err := db.StartSyncRun(run); err != nil {
+		log.Printf("数据同步：%v", err)
+	}
+
+	changes, err := apply(ctx, db, apiClient, report)
+
+	run.FinishedAt = time.Now()
+	run.Status = models.SyncStatusSucceeded
+	if err != nil {
+		run.Status = models.SyncStatusFailed
+		run.Error = err.Error()
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case models.ChangeAdded:
+			run.Added++
+		case models.ChangeUpdated:
+			run.Updated++
+		case models.ChangeRemoved:
+			run.Removed++
+		}
+	}
+	run.Deleted = report.Deleted
+	run.Fetched = report.Fetched
+	run.Upserted = report.Upserted
+	run.Rejected = len(report.RowErrors)
+	if err := db.FinishSyncRun(run, changes); err != nil {
+		log.Printf("数据同步：保存同步记录失败: %v", err)
+	}
+	if err := db.PruneSyncSnapshots(SnapshotRetention); err != nil {
+		log.Printf("数据同步：%v", err)
+	}
+	if run.Status == models.SyncStatusSucceeded {
+		if err := saveLastSuccess(db, run.FinishedAt); err != nil {
+			log.Printf("数据同步：保存同步时间失败: %v", err)
+		}
+	}
+	return err
+}
+
+// apply 对账删除、拉取并写入更新, 返回已经发生的变更。
+func apply(ctx context.Context, db *database.Service, apiClient *api.Client, report *Report) ([]models.SyncChange, error) {
+	runID := report.SyncID
+	var changes []models.SyncChange
+
+	pin, err := LoadPin(db)
+	if err != nil {
+		return changes, err
+	}
+	// 本地数据已经比固定的游标新, 说明撤销之后已经有新的更新写入, 固定不再需要;
+	// 解除之后被保留的游戏也会重新参与对账, 数据服务上确实删除了的游戏不会一直留着
+	if pin != nil {
+		if latest, err := db.GetLatestTimestamp(); err == nil && latest.After(pin.Cursor) {
+			log.Printf("数据同步：同步游标已越过撤销 %s 时的固定, 解除固定", pin.RunID)
+			if err := ClearPin(db); err != nil {
+				return changes, err
+			}
+			pin = nil
+		}
+	}
+	keep := make(map[int64]bool)
+	if pin != nil {
+		for _, id := range pin.KeepIDs {
+			keep[id] = true
+		}
+	}
 
 	remoteIDs, err := apiClient.GetAllActiveIDs()
 	if err != nil {
-		return fmt.Errorf("从API获取所有活跃ID失败: %w", err)
+		return changes, fmt.Errorf("从API获取所有活跃ID失败: %w", err)
 	}
 
 	localIDs, err := db.GetAllGameIDs()
 	if err != nil {
-		return fmt.Errorf("获取本地所有游戏ID失败: %w", err)
+		return changes, fmt.Errorf("获取本地所有游戏ID失败: %w", err)
 	}
 
 	remoteIDMap := make(map[int64]struct{}, len(remoteIDs))
@@ -26,15 +134,31 @@ func Run(db *database.Service, apiClient *api.Client) error {
 
 	var idsToDelete []int64
 	for _, id := range localIDs {
-		if _, found := remoteIDMap[id]; !found {
+		if _, found := remoteIDMap[id]; !found && !keep[id] {
 			idsToDelete = append(idsToDelete, id)
 		}
 	}
 
 	if len(idsToDelete) > 0 {
-		_, err := db.DeleteGames(idsToDelete)
+		if ctx.Err() != nil {
+			return changes, ErrLeaseLost
+		}
+		removed, err := db.GetSyncedGames(idsToDelete)
 		if err != nil {
-			return fmt.Errorf("删除本地数据库中的过时数据失败: %w", err)
+			return changes, err
+		}
+		if err := db.SaveSyncSnapshot(runID, idsToDelete, removed); err != nil {
+			return changes, err
+		}
+		deleted, err := db.DeleteGames(idsToDelete)
+		if err != nil {
+			return changes, fmt.Errorf("删除本地数据库中的过时数据失败: %w", err)
+		}
+		report.Deleted = deleted
+		for _, id := range idsToDelete {
+			if game, ok := removed[id]; ok {
+				changes = append(changes, models.SyncChange{RunID: runID, GameID: id, Title: game.TitleJP, Type: models.ChangeRemoved})
+			}
 		}
 	}
 
@@ -42,20 +166,79 @@ func Run(db *database.Service, apiClient *api.Client) error {
 	if err != nil {
 		latestTime = time.Time{}
 	}
+	if pin != nil && pin.Cursor.After(latestTime) {
+		latestTime = pin.Cursor
+	}
 
-	updates, err := apiClient.GetUpdates(latestTime)
+	updates, rejected, err := apiClient.GetUpdates(latestTime)
 	if err != nil {
-		return fmt.Errorf("从API获取更新失败: %w", err)
+		return changes, fmt.Errorf("从API获取更新失败: %w", err)
 	}
+	report.Fetched = len(updates) + len(rejected)
 
-	if len(updates) == 0 {
-		return nil
+	for _, row := range rejected {
+		log.Printf("游戏ID %d 无法解析, 已隔离: %v", row.GameID, row.Err)
+		report.RowErrors = append(report.RowErrors, models.RowError{GameID: row.GameID, Stage: models.QuarantineStageDecode, Error: row.Err.Error()})
+		if err := db.QuarantineRaw(report.SyncID, row.GameID, row.Raw, row.Err); err != nil {
+			log.Printf("%v", err)
+		}
 	}
 
-	_, err = db.UpsertGames(updates)
-	if err != nil {
-		return fmt.Errorf("更新本地数据库失败: %w", err)
+	if len(updates) > 0 {
+		if ctx.Err() != nil {
+			return changes, ErrLeaseLost
+		}
+		updateIDs := make([]int64, len(updates))
+		for i, game := range updates {
+			updateIDs[i] = game.ID
+		}
+		before, err := db.GetSyncedGames(updateIDs)
+		if err != nil {
+			return changes, err
+		}
+		if err := db.SaveSyncSnapshot(runID, updateIDs, before); err != nil {
+			return changes, err
+		}
+
+		upserted, rowErrors, err := db.UpsertGames(updates, report.SyncID)
+		if err != nil {
+			return changes, fmt.Errorf("更新本地数据库失败: %w", err)
+		}
+		report.Upserted = upserted
+		report.RowErrors = append(report.RowErrors, rowErrors...)
+
+		after, err := db.GetSyncedGames(updateIDs)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, diffUpdates(runID, updateIDs, before, after)...)
 	}
 
-	return nil
+	return changes, nil
+}
+
+// diffUpdates 比较同步前后的 games 表, 被拒绝的行在 after 中没有变化, 不会产生记录。
+func diffUpdates(runID string, ids []int64, before map[int64]models.Galgame, after map[int64]models.Galgame) []models.SyncChange {
+	var changes []models.SyncChange
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		newGame, ok := after[id]
+		if !ok {
+			continue
+		}
+		oldGame, existed := before[id]
+		if !existed {
+			changes = append(changes, models.SyncChange{RunID: runID, GameID: id, Title: newGame.TitleJP, Type: models.ChangeAdded})
+			continue
+		}
+		if fields := models.DiffGames(oldGame, newGame); len(fields) > 0 {
+			changes = append(changes, models.SyncChange{RunID: runID, GameID: id, Title: newGame.TitleJP, Type: models.ChangeUpdated, Fields: fields})
+		}
+	}
+	return changes
 }
@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"galgame-gui/internal/api"
+	"galgame-gui/internal/database"
+)
+
+// fakeService 模拟数据服务: ids 是所有活跃ID, updates 是 GetUpdates 返回的行 (JSON), fail 时返回 500。
+type fakeService struct {
+	mu      gosync.Mutex
+	ids     []int64
+	updates []string
+	fail    bool
+}
+
+func (f *fakeService) set(ids []int64, updates ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids, f.updates, f.fail = ids, updates, false
+}
+
+func (f *fakeService) setFailing() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = true
+}
+
+func (f *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var rows []string
+	if r.URL.Path == "/games/ids" {
+		for _, id := range f.ids {
+			rows = append(rows, fmt.Sprintf(`{"id":"%d"}`, id))
+		}
+	} else {
+		rows = f.updates
+	}
+	fmt.Fprintf(w, `{"data":{"rows":[%s],"result":{"code":200}}}`, strings.Join(rows, ","))
+}
+
+func newTestEnv(t *testing.T) (*database.Service, *api.Client, *fakeService) {
+	t.Helper()
+	db, err := database.NewService(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	t.Cleanup(db.Close)
+	fake := &fakeService{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return db, api.NewClient(server.URL, "public", "private"), fake
+}
+
+func mustRun(t *testing.T, db *database.Service, client *api.Client) Report {
+	t.Helper()
+	report, err := Run(db, client)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return report
+}
+
+func TestUndoLastSkipsRunsWithoutChanges(t *testing.T) {
+	db, client, fake := newTestEnv(t)
+
+	fake.set([]int64{1}, `{"id":"1","title_jp":"one"}`)
+	first := mustRun(t, db, client)
+
+	fake.set([]int64{1}, `{"id":"1","title_jp":"one (修正版)"}`)
+	second := mustRun(t, db, client)
+
+	fake.setFailing()
+	if _, err := Run(db, client); err == nil {
+		t.Fatal("Run against a failing service should return an error")
+	}
+
+	run, err := UndoLast(db)
+	if err != nil {
+		t.Fatalf("UndoLast: %v", err)
+	}
+	if run.ID != second.SyncID {
+		t.Fatalf("UndoLast undid %s, want the last run with changes %s (first was %s)", run.ID, second.SyncID, first.SyncID)
+	}
+	games, err := db.GetSyncedGames([]int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := games[1].TitleJP; got != "one" {
+		t.Errorf("title after undo = %q, want %q", got, "one")
+	}
+}
+
+func TestPinKeepsRestoredGamesUntilCursorPasses(t *testing.T) {
+	db, client, fake := newTestEnv(t)
+
+	fake.set([]int64{1, 2}, `{"id":"1","title_jp":"one"}`, `{"id":"2","title_jp":"two"}`)
+	mustRun(t, db, client)
+	fake.set([]int64{1}, `{"id":"1","title_jp":"one (bad)"}`)
+	mustRun(t, db, client)
+
+	if _, err := UndoLast(db); err != nil {
+		t.Fatalf("UndoLast: %v", err)
+	}
+	pin, err := LoadPin(db)
+	if err != nil || pin == nil {
+		t.Fatalf("LoadPin = %v, %v; want a pin", pin, err)
+	}
+	if len(pin.KeepIDs) != 1 || pin.KeepIDs[0] != 2 {
+		t.Fatalf("KeepIDs = %v, want [2]", pin.KeepIDs)
+	}
+
+	exists := func(id int64) bool {
+		games, err := db.GetSyncedGames([]int64{id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok := games[id]
+		return ok
+	}
+
+	// 固定的游标还没有被越过: 被撤销的同步删除的游戏不会再次被删除
+	fake.set([]int64{1})
+	pin.Cursor = pin.Cursor.Add(time.Hour)
+	if err := savePin(db, *pin); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, db, client)
+	if !exists(2) {
+		t.Fatal("game 2 was deleted while the pin was active")
+	}
+
+	// 本地数据已经比游标新: 解除固定, 游戏 2 重新参与对账
+	pin.Cursor = pin.Cursor.Add(-24 * time.Hour)
+	if err := savePin(db, *pin); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, db, client)
+	if exists(2) {
+		t.Error("game 2 should be reconciled away once the cursor passed the pin")
+	}
+	if pin, err := LoadPin(db); err != nil || pin != nil {
+		t.Errorf("LoadPin = %+v, %v; want the pin cleared", pin, err)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	db, client, fake := newTestEnv(t)
+
+	runs := SnapshotRetention + 2
+	for i := 0; i < runs; i++ {
+		fake.set([]int64{1}, fmt.Sprintf(`{"id":"1","title_jp":"v%d"}`, i))
+		mustRun(t, db, client)
+	}
+	for i := 0; i < SnapshotRetention; i++ {
+		if _, err := UndoLast(db); err != nil {
+			t.Fatalf("UndoLast #%d: %v", i+1, err)
+		}
+	}
+	if _, err := UndoLast(db); err == nil {
+		t.Fatal("runs older than the retention window should not be undoable")
+	}
+	games, err := db.GetSyncedGames([]int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := games[1].TitleJP, fmt.Sprintf("v%d", runs-SnapshotRetention-1); got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+}
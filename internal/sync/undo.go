@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"galgame-gui/internal/database"
+	"galgame-gui/internal/models"
+	"time"
+)
+
+const pinSettingKey = "sync_pin"
+
+// Pin 在撤销一次同步后固定同步游标。撤销会把 updated_at 恢复到同步之前, 不固定的话
+// 下一次同步会立刻重新拉取同一批数据。KeepIDs 是被撤销的同步删除、随后又恢复的游戏,
+// 固定期间对账时不再删除它们。
+type Pin struct {
+	RunID   string    `json:"run_id"`
+	Cursor  time.Time `json:"cursor"`
+	KeepIDs []int64   `json:"keep_ids"`
+}
+
+// LoadPin 读取当前的游标固定, 没有固定时返回 nil。
+func LoadPin(db *database.Service) (*Pin, error) {
+	value, ok, err := db.GetSetting(pinSettingKey)
+	if err != nil || !ok || value == "" {
+		return nil, err
+	}
+	var pin Pin
+	if err := json.Unmarshal([]byte(value), &pin); err != nil {
+		return nil, fmt.Errorf("解析同步游标固定失败: %w", err)
+	}
+	return &pin, nil
+}
+
+// ClearPin 解除游标固定, 之后的同步按本地最新的 updated_at 拉取, 可能会重新应用被撤销的数据。
+func ClearPin(db *database.Service) error {
+	return db.SetSetting(pinSettingKey, "")
+}
+
+func savePin(db *database.Service, pin Pin) error {
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return err
+	}
+	return db.SetSetting(pinSettingKey, string(data))
+}
+
+// UndoLast 撤销最近一次同步并固定游标。连续调用会依次撤销更早的同步。
+// 撤销期间持有同步锁, 其他进程正在同步时返回 ErrLocked。
+func UndoLast(db *database.Service) (models.SyncRun, error) {
+	var run models.SyncRun
+	err := WithLease(db, func(ctx context.Context) error {
+		var err error
+		run, err = undoLast(db)
+		return err
+	})
+	return run, err
+}
+
+func undoLast(db *database.Service) (models.SyncRun, error) {
+	run, err := db.LastUndoableSyncRun()
+	if err != nil {
+		return models.SyncRun{}, err
+	}
+	restored, err := db.UndoSyncRun(run.ID)
+	if err != nil {
+		return models.SyncRun{}, err
+	}
+
+	pin := Pin{RunID: run.ID, Cursor: run.FinishedAt.UTC(), KeepIDs: restored}
+	previous, err := LoadPin(db)
+	if err != nil {
+		return models.SyncRun{}, err
+	}
+	if previous != nil {
+		if previous.Cursor.After(pin.Cursor) {
+			pin.Cursor = previous.Cursor
+		}
+		pin.KeepIDs = append(pin.KeepIDs, previous.KeepIDs...)
+	}
+	if err := savePin(db, pin); err != nil {
+		return models.SyncRun{}, fmt.Errorf("固定同步游标失败: %w", err)
+	}
+
+	now := time.Now()
+	run.UndoneAt = &now
+	return run, nil
+}
@@ -55,7 +55,7 @@ func createAppOptions(app *App) *options.App {
 		BackgroundColour:  &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		AssetServer: &assetserver.Options{
 			Assets:  assets,
-			Handler: http.FileServer(http.FS(assets)),
+			Handler: app.newAssetHandler(http.FileServer(http.FS(assets))),
 		},
 
 		Menu:       nil,
@@ -66,9 +66,10 @@ func createAppOptions(app *App) *options.App {
 		Bind: []interface{}{
 			app,
 		},
-		WindowStartState: options.Normal,
-		CSSDragProperty:  "--wails-draggable",
-		CSSDragValue:     "drag",
+		SingleInstanceLock: singleInstanceLock(app, os.Args[1:]),
+		WindowStartState:   options.Normal,
+		CSSDragProperty:    "--wails-draggable",
+		CSSDragValue:       "drag",
 		Windows: &windows.Options{
 			WebviewIsTransparent:              false,
 			WindowIsTranslucent:               false,